@@ -6,4 +6,25 @@ const (
 	actionNone action = iota
 	actionAdd
 	actionDelete
+	actionBatchBegin  // value contains number of batch records
+	actionBatchCommit // marks end of complete batch
 )
+
+// Action is a kind of key change.
+type Action int8
+
+const (
+	ActionSet Action = iota + 1
+	ActionDelete
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionSet:
+		return "set"
+	case ActionDelete:
+		return "delete"
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,280 @@
+package zkv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// Batch is a set of writes applied to database atomically.
+// Batch is not safe for concurrent use.
+type Batch struct {
+	db      *Db
+	records []batchRecord
+}
+
+type batchRecord struct {
+	action     action
+	keyBytes   []byte
+	valueBytes []byte
+}
+
+// NewBatch returns new empty batch for database.
+func (db *Db) NewBatch() *Batch {
+	return &Batch{db: db}
+}
+
+// Set adds value saving for specified key to batch.
+func (b *Batch) Set(key, value interface{}) error {
+	keyBytes, err := b.db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return err
+	}
+
+	valueBytes, err := b.db.config.Codec.Encode(value)
+	if err != nil {
+		return err
+	}
+
+	b.records = append(b.records, batchRecord{actionAdd, keyBytes, valueBytes})
+
+	return nil
+}
+
+// Delete adds deletion of specified key to batch.
+func (b *Batch) Delete(key interface{}) error {
+	keyBytes, err := b.db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return err
+	}
+
+	b.records = append(b.records, batchRecord{actionDelete, keyBytes, nil})
+
+	return nil
+}
+
+// Len returns number of writes in batch.
+func (b *Batch) Len() int {
+	return len(b.records)
+}
+
+// Write applies all writes of batch atomically: after crash either all
+// or none of them are present in database.
+func (db *Db) Write(b *Batch) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	if db.config.ReadOnly {
+		return errReadOnly
+	}
+
+	if b.db != db {
+		return fmt.Errorf("batch belongs to another database")
+	}
+
+	return db.writeBatch(b.records)
+}
+
+func (db *Db) writeBatch(records []batchRecord) error {
+	// deletes of missing keys are skipped because replay rejects them
+	exists := make(map[string]bool)
+	effective := make([]batchRecord, 0, len(records))
+	for _, r := range records {
+		if r.action == actionDelete {
+			e, ok := exists[string(r.keyBytes)]
+			if !ok {
+				_, found, err := db.keys.get(r.keyBytes)
+				if err != nil {
+					return err
+				}
+				e = found
+			}
+			if !e {
+				continue
+			}
+		}
+
+		exists[string(r.keyBytes)] = r.action == actionAdd
+		effective = append(effective, r)
+	}
+
+	if len(effective) == 0 {
+		return nil
+	}
+
+	err := db.upgradeForBatches()
+	if err != nil {
+		return err
+	}
+
+	blockNum, bufLen := db.currentBlockNum, db.buf.Len()
+
+	recordCoords, err := db.appendBatch(effective)
+	if err != nil {
+		db.abortBatch(blockNum, bufLen)
+		return err
+	}
+
+	for i, r := range effective {
+		err = db.applyRecord(r.action, r.keyBytes, r.valueBytes, recordCoords[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.syncWrite()
+}
+
+// appendBatch appends batch records between begin and commit markers
+// and returns coords of appended records.
+func (db *Db) appendBatch(records []batchRecord) ([]coords, error) {
+	_, err := db.appendRecord(actionBatchBegin, nil, binary.AppendUvarint(nil, uint64(len(records))))
+	if err != nil {
+		return nil, err
+	}
+
+	recordCoords := make([]coords, len(records))
+	for i, r := range records {
+		recordCoords[i], err = db.appendRecord(r.action, r.keyBytes, r.valueBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = db.appendRecord(actionBatchCommit, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordCoords, nil
+}
+
+// abortBatch discards records of batch which failed to be appended,
+// so that next records are not counted as its records on replay.
+// Buffered records are dropped, flushed ones are terminated by empty batch.
+func (db *Db) abortBatch(blockNum int64, bufLen int) {
+	if db.currentBlockNum == blockNum {
+		db.buf.Truncate(bufLen)
+		return
+	}
+
+	// buffer contains only records of batch
+	db.buf.Reset()
+	db.writeEmptyBatch() // error of batch itself is returned to caller
+}
+
+// writeEmptyBatch writes batch without records. It terminates incomplete
+// batch at the end of file so that new records are not counted as its records.
+func (db *Db) writeEmptyBatch() error {
+	err := db.upgradeForBatches()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.appendRecord(actionBatchBegin, nil, binary.AppendUvarint(nil, 0))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.appendRecord(actionBatchCommit, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	db.replay = replayState{}
+
+	return nil
+}
+
+// upgradeForBatches raises version of file header before first batch is
+// written, so readers of older versions reject file instead of failing
+// on unknown record action. Header of version 2 has same layout.
+func (db *Db) upgradeForBatches() error {
+	if db.fileVersion >= batchVersion {
+		return nil
+	}
+
+	if db.fileVersion < 2 {
+		return fmt.Errorf("batches are not supported by file version %d, use Shrink to convert file", db.fileVersion)
+	}
+
+	f, err := os.OpenFile(db.filePath, os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteAt([]byte{byte(version)}, int64(len(headerBytes)))
+	if err == nil && db.config.Sync != SyncNone {
+		err = f.Sync()
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("write file version: %v", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	db.fileVersion = version
+
+	return nil
+}
+
+// replayState holds batch records read from file until batch commit marker.
+type replayState struct {
+	active    bool
+	remaining uint64
+	records   []batchRecord
+	coords    []coords
+}
+
+// replayRecord applies record read from file. Records of batch are applied
+// only after its commit marker, incomplete batches are discarded.
+func (db *Db) replayRecord(action action, keyBytes []byte, valueBytes []byte, c coords) error {
+	switch action {
+	case actionBatchBegin:
+		n, l := binary.Uvarint(valueBytes)
+		if l <= 0 {
+			return fmt.Errorf("invalid batch records count %v", valueBytes)
+		}
+		db.replay = replayState{active: true, remaining: n}
+
+		return nil
+	case actionBatchCommit:
+		replay := db.replay
+		db.replay = replayState{}
+		if !replay.active || replay.remaining > 0 {
+			return nil
+		}
+
+		for i, r := range replay.records {
+			err := db.applyRecord(r.action, r.keyBytes, r.valueBytes, replay.coords[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if db.replay.active {
+		if db.replay.remaining > 0 {
+			db.replay.records = append(db.replay.records, batchRecord{action, keyBytes, valueBytes})
+			db.replay.coords = append(db.replay.coords, c)
+			db.replay.remaining--
+
+			return nil
+		}
+
+		// batch is not committed
+		db.replay = replayState{}
+	}
+
+	return db.applyRecord(action, keyBytes, valueBytes, c)
+}
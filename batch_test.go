@@ -0,0 +1,227 @@
+package zkv
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch(t *testing.T) {
+	const filePath = "batch.tmp"
+	defer os.Remove(filePath)
+
+	config := *defaultConfig
+	config.BlockDataSize = 64 // force flushes inside batch
+
+	db, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+
+	err = db.Set(0, 0)
+	assert.NoError(t, err)
+
+	b := db.NewBatch()
+	for i := 1; i <= 100; i++ {
+		err = b.Set(i, i)
+		assert.NoError(t, err)
+	}
+	err = b.Delete(0)
+	assert.NoError(t, err)
+	err = b.Delete(1000) // missing key
+	assert.NoError(t, err)
+	err = b.Set(1000, 1000)
+	assert.NoError(t, err)
+	err = b.Delete(1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 104, b.Len())
+
+	err = db.Write(b)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, db.Count())
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	db, err = OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, db.Count())
+
+	for i := 1; i <= 100; i++ {
+		var got int
+		err = db.Get(i, &got)
+		assert.NoError(t, err)
+		assert.Equal(t, i, got)
+	}
+
+	var got int
+	err = db.Get(0, &got)
+	assert.Equal(t, ErrNotFound, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestBatchIncomplete(t *testing.T) {
+	const filePath = "batchIncomplete.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	err = db.Set(1, 1)
+	assert.NoError(t, err)
+
+	// simulate crash in the middle of batch
+	_, err = db.appendRecord(actionBatchBegin, nil, binary.AppendUvarint(nil, 3))
+	assert.NoError(t, err)
+	keyBytes, err := db.config.KeyCodec.Encode(2)
+	assert.NoError(t, err)
+	valueBytes, err := db.config.Codec.Encode(2)
+	assert.NoError(t, err)
+	_, err = db.appendRecord(actionAdd, keyBytes, valueBytes)
+	assert.NoError(t, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	db, err = Open(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, db.Count())
+
+	// new records must not be counted as records of incomplete batch
+	err = db.Set(3, 3)
+	assert.NoError(t, err)
+	err = db.Set(4, 4)
+	assert.NoError(t, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	db, err = Open(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, db.Count())
+
+	var got int
+	err = db.Get(2, &got)
+	assert.Equal(t, ErrNotFound, err)
+	err = db.Get(4, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, got)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestBatchUpgradesFileVersion(t *testing.T) {
+	const filePath = "batchVersion.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+	err = db.Close()
+	assert.NoError(t, err)
+
+	// same header layout as version 3
+	f, err := os.OpenFile(filePath, os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteAt([]byte{2}, int64(len(headerBytes)))
+	assert.NoError(t, err)
+	err = f.Close()
+	assert.NoError(t, err)
+
+	db, err = Open(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(2), db.fileVersion)
+
+	err = db.Set(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(2), db.fileVersion)
+
+	b := db.NewBatch()
+	err = b.Set(2, 2)
+	assert.NoError(t, err)
+	err = db.Write(b)
+	assert.NoError(t, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	f, err = os.Open(filePath)
+	assert.NoError(t, err)
+	h, err := readHeader(f)
+	assert.NoError(t, err)
+	assert.Equal(t, batchVersion, h.version)
+	err = f.Close()
+	assert.NoError(t, err)
+
+	db, err = Open(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, db.Count())
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+// failingCompressor fails to compress blocks after specified number of blocks
+type failingCompressor struct {
+	Compressor
+	blocks *int
+}
+
+func (c failingCompressor) Compress(b []byte) ([]byte, error) {
+	if *c.blocks == 0 {
+		return nil, errors.New("disk is full")
+	}
+	*c.blocks--
+
+	return c.Compressor.Compress(b)
+}
+
+func TestBatchWriteError(t *testing.T) {
+	// batch fails before and after some of its blocks are flushed
+	for _, blocks := range []int{0, 2} {
+		const filePath = "batchWriteError.tmp"
+
+		config := *defaultConfig
+		config.BlockDataSize = 64
+
+		db, err := OpenWithConfig(filePath, &config)
+		assert.NoError(t, err)
+
+		err = db.Set(0, 0)
+		assert.NoError(t, err)
+
+		b := db.NewBatch()
+		for i := 1; i <= 100; i++ {
+			err = b.Set(i, i)
+			assert.NoError(t, err)
+		}
+
+		compressor := db.config.Compressor
+		db.config.Compressor = failingCompressor{compressor, &blocks}
+		err = db.Write(b)
+		assert.Error(t, err)
+		db.config.Compressor = compressor
+
+		// records written after failed batch must not be lost
+		err = db.Set(1000, 1000)
+		assert.NoError(t, err)
+		err = db.Close()
+		assert.NoError(t, err)
+
+		db, err = OpenWithConfig(filePath, &config)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, db.Count())
+
+		var got int
+		err = db.Get(1000, &got)
+		assert.NoError(t, err)
+		assert.Equal(t, 1000, got)
+
+		err = db.Close()
+		assert.NoError(t, err)
+
+		os.Remove(filePath)
+	}
+}
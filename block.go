@@ -49,7 +49,7 @@ func readBlock(r io.Reader, compressor Compressor) (decompressedData []byte, err
 
 	b := make([]byte, int(blockLength))
 
-	_, err = r.Read(b)
+	_, err = io.ReadFull(r, b)
 	if err != nil {
 		return nil, err
 	}
@@ -61,3 +61,27 @@ func readBlock(r io.Reader, compressor Compressor) (decompressedData []byte, err
 
 	return dataBytes, nil
 }
+
+// readBlockFromBytes reads block located at specified offset of b.
+// Compressed block data is sliced from b without copying.
+func readBlockFromBytes(b []byte, offset int64, compressor Compressor) (decompressedData []byte, err error) {
+	const blockHeaderLength = 8 + 8
+
+	if offset < 0 || offset+blockHeaderLength > int64(len(b)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	blockLength := int64(binary.LittleEndian.Uint64(b[offset:]))
+	dataLength := int64(binary.LittleEndian.Uint64(b[offset+8:]))
+
+	if dataLength < 0 {
+		return nil, fmt.Errorf("unexpected data length: %d", dataLength)
+	}
+
+	start := offset + blockHeaderLength
+	if blockLength < 0 || start+blockLength > int64(len(b)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return compressor.Decompress(b[start : start+blockLength])
+}
@@ -2,6 +2,7 @@ package zkv
 
 import (
 	"bytes"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,3 +30,24 @@ func TestReadWriteBlock(t *testing.T) {
 
 	assert.Equal(t, recordBuf.Bytes(), b)
 }
+
+func TestReadBlockFromBytes(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := writeBlock(&buf, NoneCompressor, []byte("first"))
+	assert.NoError(t, err)
+	secondOffset := int64(buf.Len())
+	err = writeBlock(&buf, ZstdCompressor, []byte("second"))
+	assert.NoError(t, err)
+
+	b, err := readBlockFromBytes(buf.Bytes(), 0, NoneCompressor)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("first"), b)
+
+	b, err = readBlockFromBytes(buf.Bytes(), secondOffset, ZstdCompressor)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("second"), b)
+
+	_, err = readBlockFromBytes(buf.Bytes()[:buf.Len()-1], secondOffset, ZstdCompressor)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
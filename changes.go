@@ -0,0 +1,148 @@
+package zkv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Position is a point in storage log. Zero Position is the log start.
+type Position struct {
+	Block  int64 // file offset of block
+	Offset int64 // record offset inside block data
+}
+
+// Change describes add or delete record of storage log.
+type Change struct {
+	Action   Action
+	Key      []byte   // encoded key
+	Value    []byte   // encoded value, nil for ActionDelete
+	Position Position // position right after change, can be used to resume feed
+}
+
+// Changes calls fn for every change appended after since position in log
+// order until fn returns false. Records of uncommitted batches are skipped.
+// Only flushed blocks are read, so returned position is durable; call Flush
+// to make buffered writes visible.
+// Returns position to pass to next Changes call. Changes may be delivered
+// again after storage reopening because last partial block is rewritten.
+func (db *Db) Changes(since Position, fn func(Change) (continueIteration bool)) (Position, error) {
+	db.mu.RLock()
+	if db.closed {
+		db.mu.RUnlock()
+		return since, ErrClosed
+	}
+	var blockOffsets []int64
+	for _, offset := range db.blockInfo {
+		if offset >= since.Block {
+			blockOffsets = append(blockOffsets, offset)
+		}
+	}
+	db.mu.RUnlock()
+
+	sort.Slice(blockOffsets, func(i, j int) bool { return blockOffsets[i] < blockOffsets[j] })
+
+	pos := since
+
+	var (
+		batchActive    bool
+		batchRemaining uint64
+		batchStart     Position // position of batch begin record
+		batchChanges   []Change
+	)
+
+	var prevBlockBytes []byte
+	for _, blockOffset := range blockOffsets {
+		blockBytes, err := db.readFlushedBlock(blockOffset)
+		if err != nil {
+			return pos, err
+		}
+
+		records, err := readBlockRecords(blockBytes)
+		if err != nil {
+			return pos, err
+		}
+
+		// records of rewritten partial block are already visited
+		var skip int64
+		if len(prevBlockBytes) > 0 && bytes.HasPrefix(blockBytes, prevBlockBytes) {
+			skip = int64(len(prevBlockBytes))
+		}
+		prevBlockBytes = blockBytes
+
+		for i, r := range records {
+			if r.offset < skip || (blockOffset == since.Block && r.offset < since.Offset) {
+				continue
+			}
+
+			recordEnd := int64(len(blockBytes))
+			if i+1 < len(records) {
+				recordEnd = records[i+1].offset
+			}
+			recordPos := Position{Block: blockOffset, Offset: r.offset}
+
+			var changes []Change
+			switch r.action {
+			case actionBatchBegin:
+				n, l := binary.Uvarint(r.valueBytes)
+				if l <= 0 {
+					return pos, fmt.Errorf("invalid batch records count %v", r.valueBytes)
+				}
+				batchActive, batchRemaining, batchStart, batchChanges = true, n, recordPos, nil
+			case actionBatchCommit:
+				if batchActive && batchRemaining == 0 {
+					changes = batchChanges
+				}
+				batchActive, batchChanges = false, nil
+			default:
+				change := Change{
+					Key:      r.keyBytes,
+					Value:    r.valueBytes,
+					Position: Position{Block: blockOffset, Offset: recordEnd}}
+				if r.action == actionAdd {
+					change.Action = ActionSet
+				} else {
+					change.Action = ActionDelete
+				}
+
+				if batchActive && batchRemaining > 0 {
+					batchChanges = append(batchChanges, change)
+					batchRemaining--
+					break
+				}
+
+				// batch is not committed
+				batchActive, batchChanges = false, nil
+				changes = []Change{change}
+			}
+
+			for _, change := range changes {
+				if !fn(change) {
+					return change.Position, nil
+				}
+			}
+
+			pos = Position{Block: blockOffset, Offset: recordEnd}
+		}
+	}
+
+	// batch may be committed later
+	if batchActive {
+		return batchStart, nil
+	}
+
+	return pos, nil
+}
+
+// readFlushedBlock returns data of flushed block located at specified file offset.
+func (db *Db) readFlushedBlock(offset int64) ([]byte, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return nil, ErrClosed
+	}
+
+	return db.getBlockBytesFromFile(offset)
+}
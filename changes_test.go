@@ -0,0 +1,86 @@
+package zkv
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChanges(t *testing.T) {
+	const filePath = "changes.tmp"
+	defer os.Remove(filePath)
+
+	config := *defaultConfig
+	config.BlockDataSize = 32
+
+	db, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+
+	collect := func(since Position) ([]string, Position) {
+		var changes []string
+		pos, err := db.Changes(since, func(c Change) bool {
+			changes = append(changes, c.Action.String()+" "+string(c.Key)+"="+string(c.Value))
+			return true
+		})
+		assert.NoError(t, err)
+		return changes, pos
+	}
+
+	err = db.SetRaw([]byte("a"), []byte("1"))
+	assert.NoError(t, err)
+	err = db.SetRaw([]byte("b"), []byte("2"))
+	assert.NoError(t, err)
+
+	// buffered writes are not visible
+	changes, pos := collect(Position{})
+	assert.Empty(t, changes)
+	assert.Equal(t, Position{}, pos)
+
+	err = db.DeleteRaw([]byte("a"))
+	assert.NoError(t, err)
+	err = db.Flush()
+	assert.NoError(t, err)
+
+	changes, pos = collect(Position{})
+	assert.Equal(t, []string{"set a=1", "set b=2", "delete a="}, changes)
+
+	changes, _ = collect(pos)
+	assert.Empty(t, changes)
+
+	// stop in the middle
+	var first Change
+	stopPos, err := db.Changes(Position{}, func(c Change) bool {
+		first = c
+		return false
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, first.Position, stopPos)
+	changes, _ = collect(stopPos)
+	assert.Equal(t, []string{"set b=2", "delete a="}, changes)
+
+	// batch is delivered only after commit
+	_, err = db.appendRecord(actionBatchBegin, nil, binary.AppendUvarint(nil, 2))
+	assert.NoError(t, err)
+	_, err = db.appendRecord(actionAdd, []byte("c"), []byte("3"))
+	assert.NoError(t, err)
+	err = db.Flush()
+	assert.NoError(t, err)
+
+	changes, batchPos := collect(pos)
+	assert.Empty(t, changes)
+
+	_, err = db.appendRecord(actionAdd, []byte("d"), []byte("4"))
+	assert.NoError(t, err)
+	_, err = db.appendRecord(actionBatchCommit, nil, nil)
+	assert.NoError(t, err)
+	err = db.Flush()
+	assert.NoError(t, err)
+
+	changes, _ = collect(batchPos)
+	assert.Equal(t, []string{"set c=3", "set d=4"}, changes)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
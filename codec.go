@@ -0,0 +1,21 @@
+package zkv
+
+var (
+	availableCodecs map[int8]Codec
+)
+
+// Codec represents key and value encoder interface
+type Codec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(b []byte, valuePtr interface{}) error
+	Id() int8
+}
+
+func init() {
+	availableCodecs = make(map[int8]Codec)
+
+	availableCodecs[BinaryCodec.Id()] = BinaryCodec
+	availableCodecs[JsonCodec.Id()] = JsonCodec
+	availableCodecs[GobCodec.Id()] = GobCodec
+	availableCodecs[TupleCodec.Id()] = TupleCodec
+}
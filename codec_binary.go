@@ -0,0 +1,18 @@
+package zkv
+
+type binaryCodec struct{}
+
+// BinaryCodec encodes data with github.com/kelindar/binary.
+var BinaryCodec = new(binaryCodec)
+
+func (binaryC *binaryCodec) Id() int8 {
+	return 1
+}
+
+func (binaryC *binaryCodec) Encode(value interface{}) ([]byte, error) {
+	return Encode(value)
+}
+
+func (binaryC *binaryCodec) Decode(b []byte, valuePtr interface{}) error {
+	return Decode(b, valuePtr)
+}
@@ -0,0 +1,30 @@
+package zkv
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+type gobCodec struct{}
+
+// GobCodec encodes data with encoding/gob.
+var GobCodec = new(gobCodec)
+
+func (gobC *gobCodec) Id() int8 {
+	return 3
+}
+
+func (gobC *gobCodec) Encode(value interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	err := gob.NewEncoder(buf).Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gobC *gobCodec) Decode(b []byte, valuePtr interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(valuePtr)
+}
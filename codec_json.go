@@ -0,0 +1,20 @@
+package zkv
+
+import "encoding/json"
+
+type jsonCodec struct{}
+
+// JsonCodec encodes data with encoding/json.
+var JsonCodec = new(jsonCodec)
+
+func (jsonC *jsonCodec) Id() int8 {
+	return 2
+}
+
+func (jsonC *jsonCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonC *jsonCodec) Decode(b []byte, valuePtr interface{}) error {
+	return json.Unmarshal(b, valuePtr)
+}
@@ -0,0 +1,218 @@
+package zkv
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecs(t *testing.T) {
+	type T struct {
+		Name  string
+		Value int64
+	}
+
+	for _, codec := range availableCodecs {
+		source := T{"name", 123}
+
+		b, err := codec.Encode(source)
+		assert.NoError(t, err, "codec", codec.Id())
+
+		var got T
+		err = codec.Decode(b, &got)
+		assert.NoError(t, err, "codec", codec.Id())
+		assert.Equal(t, source, got, "codec", codec.Id())
+	}
+}
+
+func TestCodecStorage(t *testing.T) {
+	const filePath = "codec.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, &Config{Codec: JsonCodec})
+	assert.NoError(t, err)
+
+	err = db.Set("key", map[string]int{"a": 1})
+	assert.NoError(t, err)
+
+	got, err := db.GetRaw([]byte(`"key"`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(got))
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	_, err = OpenWithConfig(filePath, &Config{Codec: GobCodec})
+	assert.Error(t, err)
+
+	db, err = Open(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, JsonCodec, db.Config().Codec)
+
+	var value map[string]int
+	err = db.Get("key", &value)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1}, value)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestReadVersion0Header(t *testing.T) {
+	h, err := readHeader(bytes.NewReader([]byte{'z', 'k', 'v', 0, byte(ZstdCompressor.Id())}))
+	assert.NoError(t, err)
+	assert.Equal(t, BinaryCodec.Id(), h.codecId)
+	assert.EqualValues(t, 5, h.length())
+}
+
+func TestTupleCodecOrder(t *testing.T) {
+	ordered := []interface{}{
+		nil,
+		[]byte{},
+		[]byte{0},
+		[]byte{0, 0},
+		[]byte{1},
+		"",
+		"a",
+		"a\x00",
+		"ab",
+		"b",
+		math.MinInt64,
+		-1000,
+		-1,
+		0,
+		1,
+		1000,
+		math.MaxInt64,
+		uint64(0),
+		uint64(math.MaxUint64),
+		math.Inf(-1),
+		-1.5,
+		-0.5,
+		0.0,
+		0.5,
+		1.5,
+		math.Inf(1),
+		false,
+		true,
+	}
+
+	var prev []byte
+	for i, value := range ordered {
+		b, err := TupleCodec.Encode(value)
+		assert.NoError(t, err)
+
+		if i > 0 {
+			assert.True(t, bytes.Compare(prev, b) < 0, "%v must be lower than %v", ordered[i-1], value)
+		}
+		prev = b
+	}
+
+	// composite keys are ordered element by element
+	a, err := TupleCodec.Encode(Tuple{"user", 2, "name"})
+	assert.NoError(t, err)
+	b, err := TupleCodec.Encode(Tuple{"user", 10})
+	assert.NoError(t, err)
+	assert.True(t, bytes.Compare(a, b) < 0)
+
+	prefix, err := TupleCodec.Encode(Tuple{"user", 2})
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(a, prefix))
+}
+
+func TestTupleCodecDecode(t *testing.T) {
+	type Key struct {
+		Group string
+		Id    int32
+		Data  []byte
+		flag  bool
+	}
+
+	key := Key{"group\x00", -5, []byte{0, 1}, false}
+	b, err := TupleCodec.Encode(key)
+	assert.NoError(t, err)
+
+	var gotKey Key
+	err = TupleCodec.Decode(b, &gotKey)
+	assert.NoError(t, err)
+	assert.Equal(t, key, gotKey)
+
+	var tuple Tuple
+	err = TupleCodec.Decode(b, &tuple)
+	assert.NoError(t, err)
+	assert.Equal(t, Tuple{"group\x00", int64(-5), []byte{0, 1}}, tuple)
+
+	b, err = TupleCodec.Encode(1.25)
+	assert.NoError(t, err)
+	var f float32
+	err = TupleCodec.Decode(b, &f)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(1.25), f)
+
+	b, err = TupleCodec.Encode(300)
+	assert.NoError(t, err)
+	var i8 int8
+	err = TupleCodec.Decode(b, &i8)
+	assert.Error(t, err)
+	var s string
+	err = TupleCodec.Decode(b, &s)
+	assert.Error(t, err)
+
+	_, err = TupleCodec.Encode(map[string]int{})
+	assert.Error(t, err)
+}
+
+func TestTupleKeyCodecStorage(t *testing.T) {
+	const filePath = "tupleKeyCodec.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, &Config{KeyCodec: TupleCodec, Index: OrderedIndex})
+	assert.NoError(t, err)
+
+	for i := -50; i < 50; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+
+	var got []int
+	err = db.Range(-3, 3, func(keyBytes, valueBytes []byte) bool {
+		var key int
+		err := TupleCodec.Decode(keyBytes, &key)
+		assert.NoError(t, err)
+
+		got = append(got, key)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{-3, -2, -1, 0, 1, 2}, got)
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	_, err = OpenWithConfig(filePath, &Config{KeyCodec: BinaryCodec})
+	assert.Error(t, err)
+
+	db, err = Open(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, TupleCodec, db.Config().KeyCodec)
+	assert.Equal(t, BinaryCodec, db.Config().Codec)
+
+	var value int
+	err = db.Get(-10, &value)
+	assert.NoError(t, err)
+	assert.Equal(t, -10, value)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestReadVersion1Header(t *testing.T) {
+	h, err := readHeader(bytes.NewReader([]byte{'z', 'k', 'v', 1, byte(ZstdCompressor.Id()), byte(JsonCodec.Id())}))
+	assert.NoError(t, err)
+	assert.Equal(t, JsonCodec.Id(), h.codecId)
+	assert.Equal(t, JsonCodec.Id(), h.keyCodecId)
+	assert.EqualValues(t, 6, h.length())
+}
@@ -0,0 +1,306 @@
+package zkv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+)
+
+// Tuple represents composite key encoded by TupleCodec.
+type Tuple []interface{}
+
+// tuple element type codes, elements of different types
+// are ordered by their type codes
+const (
+	tupleNil    byte = 0x00
+	tupleBytes  byte = 0x01
+	tupleString byte = 0x02
+	tupleInt    byte = 0x10
+	tupleUint   byte = 0x11
+	tupleFloat  byte = 0x20
+	tupleFalse  byte = 0x26
+	tupleTrue   byte = 0x27
+)
+
+type tupleCodec struct{}
+
+// TupleCodec encodes keys preserving their natural order, so byte order of
+// encoded keys matches order of source values. Supports nil, bool, integers,
+// floats, strings, []byte, Tuple and structs of these types (fields are
+// encoded in declaration order). Signed and unsigned integers are ordered
+// separately, so use same type for all keys.
+var TupleCodec = new(tupleCodec)
+
+func (tupleC *tupleCodec) Id() int8 {
+	return 4
+}
+
+func (tupleC *tupleCodec) Encode(value interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	err := encodeTuple(buf, reflect.ValueOf(value))
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (tupleC *tupleCodec) Decode(b []byte, valuePtr interface{}) error {
+	v := reflect.ValueOf(valuePtr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("expected non nil pointer, got %T", valuePtr)
+	}
+
+	r := bytes.NewReader(b)
+
+	err := decodeTuple(r, v.Elem())
+	if err != nil {
+		return err
+	}
+
+	if r.Len() > 0 {
+		return fmt.Errorf("%d bytes left after decoding", r.Len())
+	}
+
+	return nil
+}
+
+// encodeTuple encodes tuple and struct as sequence of elements.
+func encodeTuple(buf *bytes.Buffer, v reflect.Value) error {
+	switch {
+	case v.IsValid() && v.Type() == reflect.TypeOf(Tuple{}):
+		for i := 0; i < v.Len(); i++ {
+			err := encodeTupleElement(buf, v.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case v.IsValid() && v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" { // unexported
+				continue
+			}
+
+			err := encodeTupleElement(buf, v.Field(i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return encodeTupleElement(buf, v)
+}
+
+func encodeTupleElement(buf *bytes.Buffer, v reflect.Value) error {
+	if v.IsValid() && v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		buf.WriteByte(tupleNil)
+		return nil
+	}
+
+	var b [8]byte
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(tupleTrue)
+		} else {
+			buf.WriteByte(tupleFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// sign bit flip makes negative numbers lower than positive
+		binary.BigEndian.PutUint64(b[:], uint64(v.Int())^(1<<63))
+		buf.WriteByte(tupleInt)
+		buf.Write(b[:])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		binary.BigEndian.PutUint64(b[:], v.Uint())
+		buf.WriteByte(tupleUint)
+		buf.Write(b[:])
+	case reflect.Float32, reflect.Float64:
+		bits := math.Float64bits(v.Float())
+		if bits&(1<<63) != 0 {
+			bits = ^bits
+		} else {
+			bits |= 1 << 63
+		}
+		binary.BigEndian.PutUint64(b[:], bits)
+		buf.WriteByte(tupleFloat)
+		buf.Write(b[:])
+	case reflect.String:
+		buf.WriteByte(tupleString)
+		writeEscapedBytes(buf, []byte(v.String()))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported tuple element type %s", v.Type())
+		}
+		buf.WriteByte(tupleBytes)
+		writeEscapedBytes(buf, v.Bytes())
+	default:
+		return fmt.Errorf("unsupported tuple element type %s", v.Type())
+	}
+
+	return nil
+}
+
+// writeEscapedBytes writes b terminated with 0x00, so shorter value
+// is ordered before longer one with same prefix. 0x00 bytes of b are
+// escaped as 0x00 0xff.
+func writeEscapedBytes(buf *bytes.Buffer, b []byte) {
+	for _, c := range b {
+		buf.WriteByte(c)
+		if c == 0x00 {
+			buf.WriteByte(0xff)
+		}
+	}
+	buf.WriteByte(0x00)
+}
+
+func readEscapedBytes(r *bytes.Reader) ([]byte, error) {
+	var result []byte
+
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("unterminated tuple bytes: %v", err)
+		}
+
+		if c != 0x00 {
+			result = append(result, c)
+			continue
+		}
+
+		next, err := r.ReadByte()
+		if err != nil || next != 0xff {
+			if err == nil {
+				r.UnreadByte()
+			}
+			return result, nil
+		}
+		result = append(result, 0x00)
+	}
+}
+
+func decodeTuple(r *bytes.Reader, v reflect.Value) error {
+	switch {
+	case v.Type() == reflect.TypeOf(Tuple{}):
+		var tuple Tuple
+		for r.Len() > 0 {
+			element, err := readTupleElement(r)
+			if err != nil {
+				return err
+			}
+			tuple = append(tuple, element)
+		}
+		v.Set(reflect.ValueOf(tuple))
+		return nil
+	case v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" { // unexported
+				continue
+			}
+
+			err := decodeTupleElement(r, v.Field(i))
+			if err != nil {
+				return fmt.Errorf("field %s: %v", v.Type().Field(i).Name, err)
+			}
+		}
+		return nil
+	}
+
+	return decodeTupleElement(r, v)
+}
+
+func decodeTupleElement(r *bytes.Reader, v reflect.Value) error {
+	element, err := readTupleElement(r)
+	if err != nil {
+		return err
+	}
+
+	if element == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	ev := reflect.ValueOf(element)
+
+	switch {
+	case v.Kind() == reflect.Interface:
+		v.Set(ev)
+	case ev.Kind() == reflect.Int64 && v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		if v.OverflowInt(ev.Int()) {
+			return fmt.Errorf("value %d overflows %s", ev.Int(), v.Type())
+		}
+		v.SetInt(ev.Int())
+	case ev.Kind() == reflect.Uint64 && v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uintptr:
+		if v.OverflowUint(ev.Uint()) {
+			return fmt.Errorf("value %d overflows %s", ev.Uint(), v.Type())
+		}
+		v.SetUint(ev.Uint())
+	case ev.Kind() == reflect.Float64 && (v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64):
+		v.SetFloat(ev.Float())
+	case ev.Kind() == v.Kind() && ev.Type().ConvertibleTo(v.Type()): // bool, string, []byte
+		v.Set(ev.Convert(v.Type()))
+	default:
+		return fmt.Errorf("can't decode %T into %s", element, v.Type())
+	}
+
+	return nil
+}
+
+// readTupleElement reads element as int64, uint64, float64, bool, string, []byte or nil.
+func readTupleElement(r *bytes.Reader) (interface{}, error) {
+	code, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	var b [8]byte
+
+	switch code {
+	case tupleNil:
+		return nil, nil
+	case tupleFalse:
+		return false, nil
+	case tupleTrue:
+		return true, nil
+	case tupleBytes:
+		return readEscapedBytes(r)
+	case tupleString:
+		s, err := readEscapedBytes(r)
+		return string(s), err
+	case tupleInt, tupleUint, tupleFloat:
+		_, err = io.ReadFull(r, b[:])
+		if err != nil {
+			return nil, fmt.Errorf("read tuple number: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown tuple element type code %d", code)
+	}
+
+	u := binary.BigEndian.Uint64(b[:])
+
+	switch code {
+	case tupleInt:
+		return int64(u ^ (1 << 63)), nil
+	case tupleUint:
+		return u, nil
+	}
+
+	// float
+	if u&(1<<63) != 0 {
+		u &^= 1 << 63
+	} else {
+		u = ^u
+	}
+
+	return math.Float64frombits(u), nil
+}
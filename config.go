@@ -1,16 +1,49 @@
 package zkv
 
+import "time"
+
 // Config represents storage config options
 type Config struct {
 	BlockDataSize int64
 	Compressor    Compressor
-	ReadOnly      bool
+	Codec         Codec
+
+	// KeyCodec encodes keys, Codec is used if not set
+	KeyCodec Codec
+
+	ReadOnly bool
+
+	// Mmap enables reading blocks from read only memory mapping of storage file
+	Mmap bool
+
+	// Index sets key index implementation
+	Index IndexType
+
+	// IndexCacheSize limits memory used by DiskIndex page cache
+	IndexCacheSize int64
+
+	// WatchBufferSize sets number of buffered events of every Watch channel
+	WatchBufferSize int
+
+	// RefreshInterval enables periodic Refresh calls for read only storage
+	RefreshInterval time.Duration
+
+	// Lock sets behaviour of storage file locking: writer takes exclusive lock,
+	// read only storage takes shared lock
+	Lock LockMode
+
+	// Sync sets when written data is fsynced to disk
+	Sync SyncMode
 }
 
 var defaultConfig = &Config{
-	BlockDataSize: 64 * 1024,
-	Compressor:    ZstdCompressor,
-	ReadOnly:      false}
+	BlockDataSize:   64 * 1024,
+	Compressor:      ZstdCompressor,
+	Codec:           BinaryCodec,
+	KeyCodec:        BinaryCodec,
+	ReadOnly:        false,
+	IndexCacheSize:  16 * 1024 * 1024,
+	WatchBufferSize: 64}
 
 // Config returens storage config (read only)
 func (db *Db) Config() Config {
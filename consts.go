@@ -2,5 +2,8 @@ package zkv
 
 var (
 	headerBytes      = []byte("zkv")
-	version     int8 = 0
+	version     int8 = 3
 )
+
+// batchVersion is first file version with batch records.
+const batchVersion int8 = 3
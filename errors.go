@@ -6,5 +6,9 @@ import (
 
 var (
 	ErrNotFound = errors.New("not found")
+	ErrClosed   = errors.New("storage is closed")
+	ErrConflict = errors.New("transaction conflicts with concurrent write")
+	ErrTxDone   = errors.New("transaction is already committed or rolled back")
+	ErrLocked   = errors.New("storage is locked by another process")
 	errReadOnly = errors.New("storage is read only")
 )
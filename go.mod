@@ -1,6 +1,6 @@
 module github.com/nxshock/zkv
 
-go 1.16
+go 1.23
 
 require (
 	github.com/kelindar/binary v1.0.14
@@ -8,3 +8,8 @@ require (
 	github.com/stretchr/testify v1.2.2
 	github.com/ulikunitz/xz v0.5.10
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
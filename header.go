@@ -10,11 +10,26 @@ import (
 type header struct {
 	version      int8
 	compressorId int8
+	codecId      int8
+	keyCodecId   int8
 }
 
-var headerLength = int64(len(headerBytes) + 1 + 1)
+// maxHeaderLength is length of header of current version.
+var maxHeaderLength = int64(len(headerBytes) + 1 + 1 + 1 + 1)
 
-func writeHeader(w io.Writer, compressorId int8) error {
+// length returns header length in bytes.
+func (h *header) length() int64 {
+	switch h.version {
+	case 0:
+		return maxHeaderLength - 2 // no codec ids
+	case 1:
+		return maxHeaderLength - 1 // no key codec id
+	}
+
+	return maxHeaderLength
+}
+
+func writeHeader(w io.Writer, compressorId int8, codecId int8, keyCodecId int8) error {
 	var buf bytes.Buffer
 
 	err := binary.Write(&buf, binary.LittleEndian, headerBytes)
@@ -32,6 +47,16 @@ func writeHeader(w io.Writer, compressorId int8) error {
 		return err
 	}
 
+	err = binary.Write(&buf, binary.LittleEndian, codecId)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(&buf, binary.LittleEndian, keyCodecId)
+	if err != nil {
+		return err
+	}
+
 	_, err = buf.WriteTo(w)
 	if err != nil {
 		return err
@@ -42,7 +67,7 @@ func writeHeader(w io.Writer, compressorId int8) error {
 
 func readHeader(r io.Reader) (*header, error) {
 	rHeaderBytes := make([]byte, len(headerBytes))
-	_, err := r.Read(rHeaderBytes)
+	_, err := io.ReadFull(r, rHeaderBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -58,10 +83,37 @@ func readHeader(r io.Reader) (*header, error) {
 		return nil, err
 	}
 
+	if header.version > version {
+		return nil, fmt.Errorf("unsupported file version %d", header.version)
+	}
+
 	err = binary.Read(r, binary.LittleEndian, &header.compressorId)
 	if err != nil {
 		return nil, err
 	}
 
+	// version 0 files are written with binary codec
+	if header.version == 0 {
+		header.codecId = BinaryCodec.Id()
+		header.keyCodecId = header.codecId
+		return header, nil
+	}
+
+	err = binary.Read(r, binary.LittleEndian, &header.codecId)
+	if err != nil {
+		return nil, err
+	}
+
+	// version 1 files encode keys with value codec
+	if header.version == 1 {
+		header.keyCodecId = header.codecId
+		return header, nil
+	}
+
+	err = binary.Read(r, binary.LittleEndian, &header.keyCodecId)
+	if err != nil {
+		return nil, err
+	}
+
 	return header, nil
 }
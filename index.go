@@ -0,0 +1,81 @@
+package zkv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// IndexType represents implementation of key index.
+type IndexType int8
+
+const (
+	// MapIndex stores full keys in memory.
+	// Fastest index, but uses most memory.
+	MapIndex IndexType = iota
+
+	// HashIndex stores only two 64-bit key hashes and packed record coords
+	// (24 bytes per slot). Keys are told apart by both hashes, so keys
+	// are never read from records on lookup.
+	HashIndex
+
+	// DiskIndex works like HashIndex, but stores slots in temporary file
+	// next to storage file and keeps only Config.IndexCacheSize bytes
+	// of recently used pages in memory. Index file is rebuilt on every open.
+	DiskIndex
+
+	// OrderedIndex stores full keys in memory in byte order.
+	// Makes Range and Seek calls fast.
+	OrderedIndex
+)
+
+// index maps encoded keys to record coords.
+type index interface {
+	get(keyBytes []byte) (c coords, exists bool, err error)
+	set(keyBytes []byte, c coords) error
+	delete(keyBytes []byte) error
+	len() int
+
+	// each calls f for every indexed key until f returns false.
+	// keyBytes is nil for indexes which does not store keys.
+	each(f func(keyBytes []byte, c coords) bool) error
+
+	close() error
+}
+
+// orderedIndex is index which visits keys in byte order.
+type orderedIndex interface {
+	index
+
+	// ascend calls f for every key >= start in ascending order until f returns false.
+	ascend(start []byte, f func(keyBytes []byte, c coords) bool) error
+}
+
+// newIndex returns key index of configured type.
+func newIndex(filePath string, config Config) (index, error) {
+	switch config.Index {
+	case MapIndex:
+		return make(mapIndex), nil
+	case HashIndex:
+		return newHashIndex(newMemorySlots)
+	case DiskIndex:
+		dir, name := filepath.Split(filePath)
+		if dir == "" {
+			dir = "."
+		}
+
+		if !unlinkIndexFiles {
+			// index files left by crashed process, files of running processes can't be removed
+			staleFiles, _ := filepath.Glob(filepath.Join(dir, name+".*.idx"))
+			for _, staleFile := range staleFiles {
+				os.Remove(staleFile)
+			}
+		}
+
+		return newHashIndex(newDiskSlotsFunc(dir, name+".*.idx", config.IndexCacheSize))
+	case OrderedIndex:
+		return newSkiplistIndex(), nil
+	}
+
+	return nil, fmt.Errorf("unknown index type %d", config.Index)
+}
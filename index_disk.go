@@ -0,0 +1,170 @@
+package zkv
+
+import (
+	"container/list"
+	"encoding/binary"
+	"os"
+	"sync"
+)
+
+const (
+	diskSlotLength = 8 + 8 + 8 // key hash + fingerprint + packed coords
+	diskPageLength = 4096
+	slotsPerPage   = diskPageLength / diskSlotLength
+)
+
+type diskPage struct {
+	num   uint64
+	data  []byte
+	dirty bool
+	elem  *list.Element
+}
+
+// diskSlots stores hash index slots in temporary file
+// with bounded cache of recently used pages.
+type diskSlots struct {
+	f        *os.File
+	slots    uint64
+	pages    map[uint64]*diskPage // [page number]page
+	lru      *list.List           // most recently used pages at front
+	maxPages int
+
+	mu sync.Mutex
+}
+
+// newDiskSlotsFunc returns constructor of disk slot storages
+// which creates index files in dir.
+func newDiskSlotsFunc(dir, pattern string, cacheSize int64) func(size uint64) (slotStorage, error) {
+	maxPages := int(cacheSize / diskPageLength)
+	if maxPages < 2 {
+		maxPages = 2
+	}
+
+	return func(size uint64) (slotStorage, error) {
+		f, err := os.CreateTemp(dir, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		if unlinkIndexFiles {
+			err = os.Remove(f.Name())
+			if err != nil {
+				f.Close()
+				return nil, err
+			}
+		}
+
+		// file is sparse, so all slots are empty
+		err = f.Truncate(int64((size + slotsPerPage - 1) / slotsPerPage * diskPageLength))
+		if err != nil {
+			f.Close()
+			if !unlinkIndexFiles {
+				os.Remove(f.Name())
+			}
+			return nil, err
+		}
+
+		return &diskSlots{
+			f:        f,
+			slots:    size,
+			pages:    make(map[uint64]*diskPage),
+			lru:      list.New(),
+			maxPages: maxPages}, nil
+	}
+}
+
+func (d *diskSlots) page(num uint64) (*diskPage, error) {
+	if p, exists := d.pages[num]; exists {
+		d.lru.MoveToFront(p.elem)
+		return p, nil
+	}
+
+	if len(d.pages) >= d.maxPages {
+		err := d.evict(d.lru.Back().Value.(*diskPage))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p := &diskPage{num: num, data: make([]byte, diskPageLength)}
+	_, err := d.f.ReadAt(p.data, int64(num*diskPageLength))
+	if err != nil {
+		return nil, err
+	}
+
+	p.elem = d.lru.PushFront(p)
+	d.pages[num] = p
+
+	return p, nil
+}
+
+func (d *diskSlots) evict(p *diskPage) error {
+	if p.dirty {
+		_, err := d.f.WriteAt(p.data, int64(p.num*diskPageLength))
+		if err != nil {
+			return err
+		}
+	}
+
+	d.lru.Remove(p.elem)
+	delete(d.pages, p.num)
+
+	return nil
+}
+
+func (d *diskSlots) slot(i uint64) (hashSlot, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, err := d.page(i / slotsPerPage)
+	if err != nil {
+		return hashSlot{}, err
+	}
+
+	offset := (i % slotsPerPage) * diskSlotLength
+
+	return hashSlot{
+		hash:        binary.LittleEndian.Uint64(p.data[offset:]),
+		fingerprint: binary.LittleEndian.Uint64(p.data[offset+8:]),
+		value:       binary.LittleEndian.Uint64(p.data[offset+16:])}, nil
+}
+
+func (d *diskSlots) setSlot(i uint64, s hashSlot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, err := d.page(i / slotsPerPage)
+	if err != nil {
+		return err
+	}
+
+	offset := (i % slotsPerPage) * diskSlotLength
+
+	binary.LittleEndian.PutUint64(p.data[offset:], s.hash)
+	binary.LittleEndian.PutUint64(p.data[offset+8:], s.fingerprint)
+	binary.LittleEndian.PutUint64(p.data[offset+16:], s.value)
+	p.dirty = true
+
+	return nil
+}
+
+func (d *diskSlots) size() uint64 {
+	return d.slots
+}
+
+func (d *diskSlots) close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pages = nil
+	d.lru = nil
+
+	err := d.f.Close()
+	if !unlinkIndexFiles {
+		if removeErr := os.Remove(d.f.Name()); err == nil {
+			err = removeErr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,7 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package zkv
+
+// unlinkIndexFiles is false because open file can't be removed on this platform,
+// so index files are removed on close and stale ones on next open.
+const unlinkIndexFiles = false
@@ -0,0 +1,7 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package zkv
+
+// unlinkIndexFiles is true because open file can be removed on this platform,
+// so index file is removed right after creation and never outlives process.
+const unlinkIndexFiles = true
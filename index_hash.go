@@ -0,0 +1,292 @@
+package zkv
+
+import (
+	"fmt"
+	"hash/fnv"
+	"hash/maphash"
+)
+
+const hashIndexInitialSize = 1024
+
+// hashSlot is hash index entry.
+type hashSlot struct {
+	hash        uint64 // key hash, 0 means empty slot
+	fingerprint uint64 // second key hash to tell apart keys with same hash
+	value       uint64 // packed coords
+}
+
+// slotStorage stores hash index slots.
+type slotStorage interface {
+	slot(i uint64) (hashSlot, error)
+	setSlot(i uint64, s hashSlot) error
+	size() uint64 // number of slots, always power of two
+	close() error
+}
+
+// hashIndex is open addressing hash table of key hashes with linear probing.
+// Keys are not stored, so keys with same hash are told apart by
+// seeded fingerprint without reading records.
+type hashIndex struct {
+	slots    slotStorage
+	newSlots func(size uint64) (slotStorage, error)
+	hash     func(keyBytes []byte) uint64
+	seed     maphash.Seed // fingerprint seed
+	count    int
+}
+
+func newHashIndex(newSlots func(size uint64) (slotStorage, error)) (*hashIndex, error) {
+	slots, err := newSlots(hashIndexInitialSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &hashIndex{slots: slots, newSlots: newSlots, hash: keyHash, seed: maphash.MakeSeed()}, nil
+}
+
+func keyHash(keyBytes []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(keyBytes)
+
+	sum := h.Sum64()
+	if sum == 0 { // reserved for empty slot
+		sum = 1
+	}
+
+	return sum
+}
+
+func packCoords(c coords) (uint64, error) {
+	if c.blockNum < 0 || c.blockNum > 1<<32-1 || c.recordOffset < 0 || c.recordOffset > 1<<32-1 {
+		return 0, fmt.Errorf("coords %+v does not fit hash index", c)
+	}
+
+	return uint64(c.blockNum)<<32 | uint64(c.recordOffset), nil
+}
+
+func unpackCoords(v uint64) coords {
+	return coords{blockNum: int64(v >> 32), recordOffset: int64(v & (1<<32 - 1))}
+}
+
+// find returns slot of specified key or empty slot where it must be placed.
+func (h *hashIndex) find(keyBytes []byte) (slot uint64, exists bool, err error) {
+	hash, fingerprint := h.hash(keyBytes), h.fingerprint(keyBytes)
+	mask := h.slots.size() - 1
+
+	for i := hash & mask; ; i = (i + 1) & mask {
+		s, err := h.slots.slot(i)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if s.hash == 0 {
+			return i, false, nil
+		}
+
+		if s.hash == hash && s.fingerprint == fingerprint {
+			return i, true, nil
+		}
+	}
+}
+
+func (h *hashIndex) fingerprint(keyBytes []byte) uint64 {
+	return maphash.Bytes(h.seed, keyBytes)
+}
+
+// findEmpty returns empty slot where entry with specified hash must be placed.
+func (h *hashIndex) findEmpty(hash uint64) (slot uint64, err error) {
+	mask := h.slots.size() - 1
+
+	for i := hash & mask; ; i = (i + 1) & mask {
+		s, err := h.slots.slot(i)
+		if err != nil {
+			return 0, err
+		}
+
+		if s.hash == 0 {
+			return i, nil
+		}
+	}
+}
+
+func (h *hashIndex) get(keyBytes []byte) (coords, bool, error) {
+	slot, exists, err := h.find(keyBytes)
+	if err != nil || !exists {
+		return coords{}, false, err
+	}
+
+	s, err := h.slots.slot(slot)
+	if err != nil {
+		return coords{}, false, err
+	}
+
+	return unpackCoords(s.value), true, nil
+}
+
+func (h *hashIndex) set(keyBytes []byte, c coords) error {
+	v, err := packCoords(c)
+	if err != nil {
+		return err
+	}
+
+	// keep load factor below 3/4
+	if uint64(h.count+1)*4 > h.slots.size()*3 {
+		err = h.grow()
+		if err != nil {
+			return fmt.Errorf("grow hash index: %v", err)
+		}
+	}
+
+	slot, exists, err := h.find(keyBytes)
+	if err != nil {
+		return err
+	}
+
+	err = h.slots.setSlot(slot, hashSlot{hash: h.hash(keyBytes), fingerprint: h.fingerprint(keyBytes), value: v})
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		h.count++
+	}
+
+	return nil
+}
+
+func (h *hashIndex) grow() error {
+	oldSlots := h.slots
+
+	newSlots, err := h.newSlots(oldSlots.size() * 2)
+	if err != nil {
+		return err
+	}
+	h.slots = newSlots
+
+	err = h.rehash(oldSlots)
+	if err != nil {
+		newSlots.close()
+		h.slots = oldSlots
+		return err
+	}
+
+	return oldSlots.close()
+}
+
+// rehash copies all entries from slots into current slots.
+func (h *hashIndex) rehash(slots slotStorage) error {
+	for i := uint64(0); i < slots.size(); i++ {
+		s, err := slots.slot(i)
+		if err != nil {
+			return err
+		}
+
+		if s.hash == 0 {
+			continue
+		}
+
+		slot, err := h.findEmpty(s.hash)
+		if err != nil {
+			return err
+		}
+
+		err = h.slots.setSlot(slot, s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *hashIndex) delete(keyBytes []byte) error {
+	slot, exists, err := h.find(keyBytes)
+	if err != nil || !exists {
+		return err
+	}
+
+	// backward shift deletion keeps probe sequences unbroken
+	mask := h.slots.size() - 1
+	for next := (slot + 1) & mask; ; next = (next + 1) & mask {
+		s, err := h.slots.slot(next)
+		if err != nil {
+			return err
+		}
+
+		if s.hash == 0 {
+			break
+		}
+
+		home := s.hash & mask
+
+		// move entry if its home slot is not in (slot, next] cyclic range
+		if (slot <= next && (home <= slot || home > next)) ||
+			(slot > next && home <= slot && home > next) {
+			err = h.slots.setSlot(slot, s)
+			if err != nil {
+				return err
+			}
+			slot = next
+		}
+	}
+
+	err = h.slots.setSlot(slot, hashSlot{})
+	if err != nil {
+		return err
+	}
+	h.count--
+
+	return nil
+}
+
+func (h *hashIndex) len() int {
+	return h.count
+}
+
+func (h *hashIndex) each(f func(keyBytes []byte, c coords) bool) error {
+	for i := uint64(0); i < h.slots.size(); i++ {
+		s, err := h.slots.slot(i)
+		if err != nil {
+			return err
+		}
+
+		if s.hash == 0 {
+			continue
+		}
+
+		if !f(nil, unpackCoords(s.value)) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (h *hashIndex) close() error {
+	return h.slots.close()
+}
+
+// memorySlots stores hash index slots in memory.
+type memorySlots []hashSlot
+
+func newMemorySlots(size uint64) (slotStorage, error) {
+	m := make(memorySlots, size)
+	return &m, nil
+}
+
+func (m *memorySlots) slot(i uint64) (hashSlot, error) {
+	return (*m)[i], nil
+}
+
+func (m *memorySlots) setSlot(i uint64, s hashSlot) error {
+	(*m)[i] = s
+	return nil
+}
+
+func (m *memorySlots) size() uint64 {
+	return uint64(len(*m))
+}
+
+func (m *memorySlots) close() error {
+	*m = nil
+	return nil
+}
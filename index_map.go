@@ -0,0 +1,36 @@
+package zkv
+
+type mapIndex map[string]coords // [key]block number + record offset
+
+func (m mapIndex) get(keyBytes []byte) (coords, bool, error) {
+	c, exists := m[string(keyBytes)]
+	return c, exists, nil
+}
+
+func (m mapIndex) set(keyBytes []byte, c coords) error {
+	m[string(keyBytes)] = c
+	return nil
+}
+
+func (m mapIndex) delete(keyBytes []byte) error {
+	delete(m, string(keyBytes))
+	return nil
+}
+
+func (m mapIndex) len() int {
+	return len(m)
+}
+
+func (m mapIndex) each(f func(keyBytes []byte, c coords) bool) error {
+	for keyBytes, c := range m {
+		if !f([]byte(keyBytes), c) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (m mapIndex) close() error {
+	return nil
+}
@@ -0,0 +1,130 @@
+package zkv
+
+import (
+	"bytes"
+	"math/rand"
+)
+
+const skiplistMaxLevel = 32
+
+type skiplistNode struct {
+	keyBytes []byte
+	c        coords
+	next     []*skiplistNode
+}
+
+// skiplistIndex stores full keys in memory in byte order.
+type skiplistIndex struct {
+	head  *skiplistNode
+	level int
+	count int
+	rnd   *rand.Rand
+}
+
+func newSkiplistIndex() *skiplistIndex {
+	return &skiplistIndex{
+		head:  &skiplistNode{next: make([]*skiplistNode, skiplistMaxLevel)},
+		level: 1,
+		rnd:   rand.New(rand.NewSource(1))}
+}
+
+// seek returns first node with key >= keyBytes.
+// If update is not nil, it is filled with last nodes before found one on every level.
+func (s *skiplistIndex) seek(keyBytes []byte, update []*skiplistNode) *skiplistNode {
+	n := s.head
+	for level := s.level - 1; level >= 0; level-- {
+		for n.next[level] != nil && bytes.Compare(n.next[level].keyBytes, keyBytes) < 0 {
+			n = n.next[level]
+		}
+
+		if update != nil {
+			update[level] = n
+		}
+	}
+
+	return n.next[0]
+}
+
+func (s *skiplistIndex) randomLevel() int {
+	level := 1
+	for level < skiplistMaxLevel && s.rnd.Intn(4) == 0 {
+		level++
+	}
+
+	return level
+}
+
+func (s *skiplistIndex) get(keyBytes []byte) (coords, bool, error) {
+	n := s.seek(keyBytes, nil)
+	if n == nil || !bytes.Equal(n.keyBytes, keyBytes) {
+		return coords{}, false, nil
+	}
+
+	return n.c, true, nil
+}
+
+func (s *skiplistIndex) set(keyBytes []byte, c coords) error {
+	update := make([]*skiplistNode, skiplistMaxLevel)
+
+	n := s.seek(keyBytes, update)
+	if n != nil && bytes.Equal(n.keyBytes, keyBytes) {
+		n.c = c
+		return nil
+	}
+
+	level := s.randomLevel()
+	for ; s.level < level; s.level++ {
+		update[s.level] = s.head
+	}
+
+	n = &skiplistNode{
+		keyBytes: append([]byte{}, keyBytes...),
+		c:        c,
+		next:     make([]*skiplistNode, level)}
+
+	for i := 0; i < level; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+	s.count++
+
+	return nil
+}
+
+func (s *skiplistIndex) delete(keyBytes []byte) error {
+	update := make([]*skiplistNode, skiplistMaxLevel)
+
+	n := s.seek(keyBytes, update)
+	if n == nil || !bytes.Equal(n.keyBytes, keyBytes) {
+		return nil
+	}
+
+	for i := range n.next {
+		update[i].next[i] = n.next[i]
+	}
+	s.count--
+
+	return nil
+}
+
+func (s *skiplistIndex) len() int {
+	return s.count
+}
+
+func (s *skiplistIndex) each(f func(keyBytes []byte, c coords) bool) error {
+	return s.ascend(nil, f)
+}
+
+func (s *skiplistIndex) ascend(start []byte, f func(keyBytes []byte, c coords) bool) error {
+	for n := s.seek(start, nil); n != nil; n = n.next[0] {
+		if !f(n.keyBytes, n.c) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *skiplistIndex) close() error {
+	return nil
+}
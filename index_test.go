@@ -0,0 +1,192 @@
+package zkv
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexes(t *testing.T) {
+	for _, indexType := range []IndexType{MapIndex, HashIndex, DiskIndex, OrderedIndex} {
+		idx, err := newIndex("indexes.tmp", Config{Index: indexType, IndexCacheSize: 4 * diskPageLength})
+		assert.NoError(t, err)
+
+		expected := make(map[string]coords)
+		rnd := rand.New(rand.NewSource(1))
+
+		for i := 0; i < 100000; i++ {
+			keyBytes := []byte(strconv.Itoa(rnd.Intn(5000)))
+
+			if rnd.Intn(3) == 0 {
+				err = idx.delete(keyBytes)
+				assert.NoError(t, err)
+				delete(expected, string(keyBytes))
+				continue
+			}
+
+			c := coords{blockNum: int64(i), recordOffset: int64(rnd.Intn(1 << 20))}
+			err = idx.set(keyBytes, c)
+			assert.NoError(t, err)
+			expected[string(keyBytes)] = c
+		}
+
+		assert.Equal(t, len(expected), idx.len(), "index type", indexType)
+
+		for i := 0; i < 5000; i++ {
+			keyBytes := []byte(strconv.Itoa(i))
+
+			expectedCoords, expectedExists := expected[string(keyBytes)]
+			gotCoords, gotExists, err := idx.get(keyBytes)
+			assert.NoError(t, err)
+			assert.Equal(t, expectedExists, gotExists, "index type", indexType)
+			assert.Equal(t, expectedCoords, gotCoords, "index type", indexType)
+		}
+
+		var count int
+		err = idx.each(func(_ []byte, c coords) bool {
+			count++
+			return true
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, len(expected), count, "index type", indexType)
+
+		err = idx.close()
+		assert.NoError(t, err)
+	}
+
+	indexFiles, err := filepath.Glob("indexes.tmp.*.idx")
+	assert.NoError(t, err)
+	assert.Empty(t, indexFiles)
+}
+
+func TestHashIndexStorage(t *testing.T) {
+	testIndexStorage(t, &Config{BlockDataSize: 256, Index: HashIndex})
+}
+
+func TestDiskIndexStorage(t *testing.T) {
+	testIndexStorage(t, &Config{BlockDataSize: 256, Index: DiskIndex, IndexCacheSize: 1})
+}
+
+func testIndexStorage(t *testing.T, config *Config) {
+	const filePath = "indexStorage.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, config)
+	assert.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+	for i := 0; i < 1000; i += 2 {
+		err = db.Delete(i)
+		assert.NoError(t, err)
+	}
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	db, err = OpenWithConfig(filePath, config)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, db.Count())
+
+	for i := 0; i < 1000; i++ {
+		var got int
+		err = db.Get(i, &got)
+		if i%2 == 0 {
+			assert.Equal(t, ErrNotFound, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, i, got)
+		}
+	}
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestHashIndexCollisions(t *testing.T) {
+	for _, config := range []*Config{{Index: HashIndex}, {Index: DiskIndex}} {
+		const filePath = "hashIndexCollisions.tmp"
+
+		db, err := OpenWithConfig(filePath, config)
+		assert.NoError(t, err)
+
+		// all keys have same hash
+		db.keys.(*hashIndex).hash = func([]byte) uint64 { return 42 }
+
+		for _, key := range []string{"a", "b", "c"} {
+			err = db.SetRaw([]byte(key), []byte(key))
+			assert.NoError(t, err)
+		}
+		err = db.SetRaw([]byte("b"), []byte("B"))
+		assert.NoError(t, err)
+		assert.Equal(t, 3, db.Count())
+
+		// missing key with same hash does not delete existing key
+		err = db.DeleteRaw([]byte("d"))
+		assert.NoError(t, err)
+
+		b := db.NewBatch()
+		err = b.Delete("e")
+		assert.NoError(t, err)
+		err = db.Write(b)
+		assert.NoError(t, err)
+
+		err = db.DeleteRaw([]byte("a"))
+		assert.NoError(t, err)
+
+		for key, expected := range map[string]string{"b": "B", "c": "c"} {
+			got, err := db.GetRaw([]byte(key))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, string(got))
+		}
+		_, err = db.GetRaw([]byte("a"))
+		assert.Equal(t, ErrNotFound, err)
+		assert.Equal(t, 2, db.Count())
+
+		err = db.Close()
+		assert.NoError(t, err)
+
+		db, err = OpenWithConfig(filePath, config)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, db.Count())
+		err = db.Close()
+		assert.NoError(t, err)
+
+		os.Remove(filePath)
+	}
+}
+
+func TestDiskIndexFiles(t *testing.T) {
+	const filePath = "diskIndexFiles.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, &Config{Index: DiskIndex})
+	assert.NoError(t, err)
+
+	for i := 0; i < 2000; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+
+	indexFiles, err := filepath.Glob(filePath + ".*.idx")
+	assert.NoError(t, err)
+	if unlinkIndexFiles {
+		// index file is removed right after creation, so crash can't leave it
+		assert.Empty(t, indexFiles)
+	} else {
+		assert.NotEmpty(t, indexFiles)
+	}
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	indexFiles, err = filepath.Glob(filePath + ".*.idx")
+	assert.NoError(t, err)
+	assert.Empty(t, indexFiles)
+}
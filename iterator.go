@@ -0,0 +1,167 @@
+package zkv
+
+import "bytes"
+
+// Iterator iterates over records in written order.
+// Storage lock is held only inside Next and Seek calls, so storage can be
+// read and modified between steps. Records written after iterator position
+// are visited, replaced and deleted records are skipped.
+type Iterator struct {
+	db *Db
+
+	blockNum   int64  // block number of next record
+	offset     int64  // offset of next record in block
+	blockBytes []byte // cached bytes of flushed block
+
+	keyBytes   []byte
+	valueBytes []byte
+
+	err    error
+	closed bool
+}
+
+// NewIterator returns iterator positioned before first record.
+func (db *Db) NewIterator() *Iterator {
+	return &Iterator{db: db}
+}
+
+// Next moves iterator to next record.
+// Returns false if there are no more records or error occurred.
+// Next can be called again after false result to check for new records.
+func (it *Iterator) Next() bool {
+	if it.closed || it.err != nil {
+		return false
+	}
+
+	it.db.mu.RLock()
+	defer it.db.mu.RUnlock()
+
+	if it.db.closed {
+		it.err = ErrClosed
+		return false
+	}
+
+	for it.blockNum <= it.db.currentBlockNum {
+		blockBytes, err := it.block()
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		if it.offset >= int64(len(blockBytes)) {
+			if it.blockNum == it.db.currentBlockNum {
+				// end of write buffer, new records may be added later
+				return false
+			}
+
+			it.blockNum++
+			it.offset = 0
+			it.blockBytes = nil
+			continue
+		}
+
+		recordOffset := it.offset
+		r := bytes.NewReader(blockBytes[recordOffset:])
+
+		action, keyBytes, valueBytes, err := readRecord(r)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.offset = int64(len(blockBytes)) - int64(r.Len())
+
+		live, err := it.db.isLive(action, keyBytes, coords{blockNum: it.blockNum, recordOffset: recordOffset})
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if !live {
+			continue
+		}
+
+		it.keyBytes, it.valueBytes = keyBytes, valueBytes
+		return true
+	}
+
+	return false
+}
+
+// block returns bytes of current block.
+// Write buffer is never cached because it may grow between calls.
+func (it *Iterator) block() ([]byte, error) {
+	if it.blockNum == it.db.currentBlockNum {
+		return it.db.buf.Bytes(), nil
+	}
+
+	if it.blockBytes == nil {
+		blockBytes, err := it.db.getBlockBytes(it.blockNum)
+		if err != nil {
+			return nil, err
+		}
+		it.blockBytes = blockBytes
+	}
+
+	return it.blockBytes, nil
+}
+
+// Seek positions iterator so next Next call returns record of specified key
+// and then continues in written order.
+// Returns ErrNotFound if key does not exists.
+func (it *Iterator) Seek(key interface{}) error {
+	if it.closed {
+		return ErrClosed
+	}
+
+	keyBytes, err := it.db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return err
+	}
+
+	it.db.mu.RLock()
+	defer it.db.mu.RUnlock()
+
+	if it.db.closed {
+		return ErrClosed
+	}
+
+	c, exists, err := it.db.keys.get(keyBytes)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	if c.blockNum != it.blockNum {
+		it.blockBytes = nil
+	}
+	it.blockNum, it.offset = c.blockNum, c.recordOffset
+	it.keyBytes, it.valueBytes = nil, nil
+	it.err = nil
+
+	return nil
+}
+
+// Key returns encoded key of current record.
+func (it *Iterator) Key() []byte {
+	return it.keyBytes
+}
+
+// Value returns encoded value of current record.
+func (it *Iterator) Value() []byte {
+	return it.valueBytes
+}
+
+// Err returns first error occurred during iteration.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Close releases iterator resources.
+func (it *Iterator) Close() error {
+	it.closed = true
+	it.blockBytes = nil
+	it.keyBytes, it.valueBytes = nil, nil
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package zkv
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterator(t *testing.T) {
+	const filePath = "iterator.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, &Config{BlockDataSize: 64})
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+
+	it1 := db.NewIterator()
+	it2 := db.NewIterator()
+
+	var got1, got2 []int
+	for it1.Next() && it2.Next() {
+		var key int
+
+		err = Decode(it1.Key(), &key)
+		assert.NoError(t, err)
+		got1 = append(got1, key)
+
+		err = Decode(it2.Value(), &key)
+		assert.NoError(t, err)
+		got2 = append(got2, key)
+
+		// storage is not locked between steps
+		if key%10 == 0 {
+			err = db.Delete(key + 5)
+			assert.NoError(t, err)
+		}
+	}
+	assert.NoError(t, it1.Err())
+	assert.NoError(t, it2.Err())
+	assert.Len(t, got1, 90)
+	assert.Equal(t, got1, got2)
+
+	// new records are visited by finished iterator
+	err = db.Set(1000, 1000)
+	assert.NoError(t, err)
+	assert.True(t, it1.Next())
+
+	err = it1.Seek(50)
+	assert.NoError(t, err)
+	assert.True(t, it1.Next())
+	var key int
+	err = Decode(it1.Key(), &key)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, key)
+
+	err = it1.Seek(5)
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.NoError(t, it1.Close())
+	assert.NoError(t, it2.Close())
+	assert.False(t, it1.Next())
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
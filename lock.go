@@ -0,0 +1,15 @@
+package zkv
+
+// LockMode sets behaviour of storage file locking.
+type LockMode int8
+
+const (
+	// LockFailFast returns ErrLocked if storage is locked by another process
+	LockFailFast LockMode = iota
+
+	// LockWait waits until storage is unlocked by another process
+	LockWait
+
+	// LockNone disables storage file locking
+	LockNone
+)
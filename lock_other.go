@@ -0,0 +1,10 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package zkv
+
+import "os"
+
+// lockFile does nothing: file locking is not supported on this platform.
+func lockFile(f *os.File, mode LockMode, exclusive bool) error {
+	return nil
+}
@@ -0,0 +1,80 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package zkv
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	const filePath = "lock.tmp"
+	defer os.Remove(filePath)
+
+	w, err := Open(filePath)
+	assert.NoError(t, err)
+
+	_, err = Open(filePath)
+	assert.Equal(t, ErrLocked, err)
+
+	readerConfig := *defaultConfig
+	readerConfig.ReadOnly = true
+	_, err = OpenWithConfig(filePath, &readerConfig)
+	assert.Equal(t, ErrLocked, err)
+
+	err = w.Close()
+	assert.NoError(t, err)
+
+	// readers share lock
+	r1, err := OpenWithConfig(filePath, &readerConfig)
+	assert.NoError(t, err)
+	r2, err := OpenWithConfig(filePath, &readerConfig)
+	assert.NoError(t, err)
+
+	_, err = Open(filePath)
+	assert.Equal(t, ErrLocked, err)
+
+	noLockConfig := *defaultConfig
+	noLockConfig.Lock = LockNone
+	w, err = OpenWithConfig(filePath, &noLockConfig)
+	assert.NoError(t, err)
+	err = w.Close()
+	assert.NoError(t, err)
+
+	err = r1.Close()
+	assert.NoError(t, err)
+	err = r2.Close()
+	assert.NoError(t, err)
+}
+
+func TestLockWait(t *testing.T) {
+	const filePath = "lockWait.tmp"
+	defer os.Remove(filePath)
+
+	w, err := Open(filePath)
+	assert.NoError(t, err)
+
+	closed := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(closed)
+		w.Close()
+	}()
+
+	config := *defaultConfig
+	config.Lock = LockWait
+	db, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+
+	select {
+	case <-closed:
+	default:
+		t.Fatal("storage opened before lock release")
+	}
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
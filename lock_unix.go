@@ -0,0 +1,36 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package zkv
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes advisory lock of file: exclusive for writers, shared for readers.
+// Lock is released when file is closed.
+func lockFile(f *os.File, mode LockMode, exclusive bool) error {
+	if mode == LockNone {
+		return nil
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if mode == LockFailFast {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.EWOULDBLOCK {
+			return ErrLocked
+		}
+
+		return err
+	}
+}
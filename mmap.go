@@ -0,0 +1,64 @@
+package zkv
+
+import "fmt"
+
+// mapping represents read only memory mapping of storage file.
+type mapping struct {
+	data []byte // current mapping, may be larger than file
+
+	// previous mappings, kept until close because
+	// block bytes returned by readBlock may still point into them
+	retired [][]byte
+}
+
+// mappingSize returns mapping length for specified file size.
+// Mapping reserves space for future blocks to reduce remap count.
+func mappingSize(fileSize int64) int64 {
+	const minMappingSize = 1024 * 1024
+
+	if fileSize < minMappingSize/2 {
+		return minMappingSize
+	}
+
+	return fileSize * 2
+}
+
+func newMapping(fd uintptr, fileSize int64) (*mapping, error) {
+	data, err := mmapFile(fd, mappingSize(fileSize))
+	if err != nil {
+		return nil, fmt.Errorf("mmap: %v", err)
+	}
+
+	return &mapping{data: data}, nil
+}
+
+// grow remaps file if it does not fit current mapping.
+func (m *mapping) grow(fd uintptr, fileSize int64) error {
+	if fileSize <= int64(len(m.data)) {
+		return nil
+	}
+
+	data, err := mmapFile(fd, mappingSize(fileSize))
+	if err != nil {
+		return fmt.Errorf("mmap: %v", err)
+	}
+
+	m.retired = append(m.retired, m.data)
+	m.data = data
+
+	return nil
+}
+
+func (m *mapping) close() error {
+	var err error
+	for _, data := range append(m.retired, m.data) {
+		if unmapErr := munmapFile(data); err == nil {
+			err = unmapErr
+		}
+	}
+
+	m.data = nil
+	m.retired = nil
+
+	return err
+}
@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package zkv
+
+import "errors"
+
+var errMmapNotSupported = errors.New("mmap is not supported on this platform")
+
+func mmapFile(fd uintptr, length int64) ([]byte, error) {
+	return nil, errMmapNotSupported
+}
+
+func munmapFile(data []byte) error {
+	return errMmapNotSupported
+}
@@ -0,0 +1,46 @@
+package zkv
+
+// SetRaw saves value bytes for specified key bytes as is, without encoding.
+// Raw keys share key space with encoded keys of Set.
+func (db *Db) SetRaw(keyBytes, valueBytes []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	if db.config.ReadOnly {
+		return errReadOnly
+	}
+
+	return db.writeRecord(actionAdd, keyBytes, valueBytes)
+}
+
+// GetRaw returns value bytes of specified key bytes as is, without decoding.
+func (db *Db) GetRaw(keyBytes []byte) ([]byte, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return nil, ErrClosed
+	}
+
+	return db.getBytes(keyBytes)
+}
+
+// DeleteRaw deletes value of specified key bytes.
+func (db *Db) DeleteRaw(keyBytes []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	if db.config.ReadOnly {
+		return errReadOnly
+	}
+
+	return db.deleteBytes(keyBytes)
+}
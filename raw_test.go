@@ -0,0 +1,55 @@
+package zkv
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaw(t *testing.T) {
+	const filePath = "raw.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	err = db.SetRaw([]byte("key"), []byte("value"))
+	assert.NoError(t, err)
+
+	got, err := db.GetRaw([]byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), got)
+
+	// raw and encoded keys share key space
+	keyBytes, err := Encode(1)
+	assert.NoError(t, err)
+	valueBytes, err := Encode("one")
+	assert.NoError(t, err)
+	err = db.SetRaw(keyBytes, valueBytes)
+	assert.NoError(t, err)
+
+	var value string
+	err = db.Get(1, &value)
+	assert.NoError(t, err)
+	assert.Equal(t, "one", value)
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	db, err = Open(filePath)
+	assert.NoError(t, err)
+
+	got, err = db.GetRaw([]byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), got)
+
+	err = db.DeleteRaw([]byte("key"))
+	assert.NoError(t, err)
+
+	_, err = db.GetRaw([]byte("key"))
+	assert.Equal(t, ErrNotFound, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
@@ -23,13 +23,13 @@ func writeRecord2(w io.Writer, action action, keyBytes []byte, valueBytes []byte
 	}
 
 	switch action {
-	case actionAdd:
+	case actionAdd, actionBatchBegin:
 		err = enc.Encode(valueBytes)
 		if err != nil {
 			return err
 		}
 
-	case actionDelete:
+	case actionDelete, actionBatchCommit:
 		// no additional fields
 	default:
 		return fmt.Errorf("can't write unknown action %v", action)
@@ -70,16 +70,51 @@ func readRecord(r reader) (action action, keyBytes []byte, valueBytes []byte, er
 	}
 
 	switch action {
-	case actionAdd:
+	case actionAdd, actionBatchBegin:
 		err = dec.Decode(&valueBytes)
 		if err != nil {
 			return actionNone, nil, nil, err
 		}
 
 		return action, keyBytes, valueBytes, nil
-	case actionDelete:
+	case actionDelete, actionBatchCommit:
 		return action, keyBytes, nil, nil
 	}
 
 	return actionNone, nil, nil, fmt.Errorf("unknown action %d", action)
 }
+
+// readRecordAt reads record located at specified offset of block data.
+func readRecordAt(blockBytes []byte, offset int64) (action action, keyBytes []byte, valueBytes []byte, err error) {
+	if offset < 0 || offset >= int64(len(blockBytes)) {
+		return actionNone, nil, nil, fmt.Errorf("record offset %d is out of block bounds", offset)
+	}
+
+	return readRecord(bytes.NewReader(blockBytes[offset:]))
+}
+
+type record struct {
+	offset     int64
+	action     action
+	keyBytes   []byte
+	valueBytes []byte
+}
+
+// readBlockRecords reads all records of block data.
+func readBlockRecords(blockBytes []byte) ([]record, error) {
+	var records []record
+
+	r := bytes.NewReader(blockBytes)
+	for r.Len() > 0 {
+		offset := int64(len(blockBytes) - r.Len())
+
+		action, keyBytes, valueBytes, err := readRecord(r)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record{offset, action, keyBytes, valueBytes})
+	}
+
+	return records, nil
+}
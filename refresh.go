@@ -0,0 +1,48 @@
+package zkv
+
+import "time"
+
+// Refresh reads blocks appended to storage file by writer process after
+// open or previous Refresh call and applies their records.
+// Does nothing for writable storage.
+func (db *Db) Refresh() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	if !db.config.ReadOnly {
+		return nil
+	}
+
+	err := db.readBlocks()
+	if err != nil {
+		return err
+	}
+
+	if db.mmap != nil {
+		return db.mmap.grow(db.f.Fd(), db.dataEnd)
+	}
+
+	return nil
+}
+
+// autoRefresh calls Refresh every interval until stop is closed.
+// Errors are ignored, they are returned by next manual Refresh call.
+func (db *Db) autoRefresh(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if db.Refresh() == ErrClosed {
+				return
+			}
+		}
+	}
+}
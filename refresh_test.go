@@ -0,0 +1,184 @@
+package zkv
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefresh(t *testing.T) {
+	const filePath = "refresh.tmp"
+	defer os.Remove(filePath)
+
+	config := *defaultConfig
+	config.BlockDataSize = 64
+
+	w, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err = w.Set(i, i)
+		assert.NoError(t, err)
+	}
+	err = w.Flush()
+	assert.NoError(t, err)
+
+	readerConfig := config
+	readerConfig.ReadOnly = true
+	readerConfig.Lock = LockNone // writer holds exclusive lock
+	r, err := OpenWithConfig(filePath, &readerConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, r.Count())
+
+	events, cancel := r.Watch(nil)
+	defer cancel()
+
+	for i := 10; i < 20; i++ {
+		err = w.Set(i, i)
+		assert.NoError(t, err)
+	}
+	err = w.Delete(0)
+	assert.NoError(t, err)
+	err = w.Flush()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 10, r.Count())
+	err = r.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, 19, r.Count())
+	assert.Len(t, events, 11)
+
+	var got int
+	err = r.Get(15, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 15, got)
+	err = r.Get(0, &got)
+	assert.Equal(t, ErrNotFound, err)
+
+	// nothing new
+	err = r.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, 19, r.Count())
+
+	err = w.Close()
+	assert.NoError(t, err)
+	err = r.Close()
+	assert.NoError(t, err)
+}
+
+func TestRefreshInterval(t *testing.T) {
+	const filePath = "refreshInterval.tmp"
+	defer os.Remove(filePath)
+
+	w, err := Open(filePath)
+	assert.NoError(t, err)
+	err = w.Flush()
+	assert.NoError(t, err)
+
+	config := *defaultConfig
+	config.ReadOnly = true
+	config.RefreshInterval = 10 * time.Millisecond
+	config.Lock = LockNone // writer holds exclusive lock
+	r, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+
+	events, cancel := r.Watch(nil)
+	defer cancel()
+
+	err = w.Set(1, 1)
+	assert.NoError(t, err)
+	err = w.Flush()
+	assert.NoError(t, err)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, ActionSet, event.Action)
+	case <-time.After(5 * time.Second):
+		t.Fatal("appended record is not refreshed")
+	}
+
+	err = r.Close()
+	assert.NoError(t, err)
+	err = w.Close()
+	assert.NoError(t, err)
+}
+
+func TestReadOnlyTornTail(t *testing.T) {
+	const filePath = "tornTail.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+	err = db.Set(1, 1)
+	assert.NoError(t, err)
+	err = db.Close()
+	assert.NoError(t, err)
+
+	// block which is being written by another process
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	_, err = f.Write([]byte{1, 2, 3})
+	assert.NoError(t, err)
+	err = f.Close()
+	assert.NoError(t, err)
+
+	config := *defaultConfig
+	config.ReadOnly = true
+	db, err = OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, db.Count())
+
+	err = db.Refresh()
+	assert.NoError(t, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestReopenRewrittenBlock(t *testing.T) {
+	const filePath = "reopenRewrittenBlock.tmp"
+	defer os.Remove(filePath)
+
+	config := *defaultConfig
+	config.BlockDataSize = 64
+
+	db, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+	err = db.Flush()
+	assert.NoError(t, err)
+	err = db.Delete(0)
+	assert.NoError(t, err)
+	err = db.Close()
+	assert.NoError(t, err)
+
+	// partial last block is restored and written again with new records
+	for i := 0; i < 2; i++ {
+		db, err = OpenWithConfig(filePath, &config)
+		assert.NoError(t, err)
+		err = db.Set(100+i, i)
+		assert.NoError(t, err)
+		err = db.Close()
+		assert.NoError(t, err)
+	}
+
+	db, err = OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, 21, db.Count())
+
+	var changes int
+	_, err = db.Changes(Position{}, func(c Change) bool {
+		changes++
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 22, changes) // restored last block is not flushed yet
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,431 @@
+package zkv
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type keyCoords struct {
+	keyBytes []byte
+	c        coords
+}
+
+type cachedBlock struct {
+	num   int64
+	bytes []byte
+}
+
+// Range calls f for every record with start <= key < end in byte order of
+// encoded keys until f returns false. nil start or end means unbounded range.
+// Few recently read blocks are cached, so records of the same block
+// located near each other in key order are read from single block read.
+// Storage opened with OrderedIndex does not sort keys on every call.
+func (db *Db) Range(start, end interface{}, f func(keyBytes, valueBytes []byte) (continueIteration bool)) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	startBytes, err := db.encodeBound(start)
+	if err != nil {
+		return err
+	}
+
+	endBytes, err := db.encodeBound(end)
+	if err != nil {
+		return err
+	}
+
+	records, err := db.rangeKeys(startBytes, endBytes, 0)
+	if err != nil {
+		return err
+	}
+
+	return db.readRecords(records, f)
+}
+
+// Seek returns first record with key >= specified key in byte order of encoded keys.
+// Returns ErrNotFound if there is no such record.
+func (db *Db) Seek(key interface{}) (keyBytes, valueBytes []byte, err error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return nil, nil, ErrClosed
+	}
+
+	startBytes, err := db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records, err := db.rangeKeys(startBytes, nil, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil, ErrNotFound
+	}
+
+	err = db.readRecords(records, func(gotKeyBytes, gotValueBytes []byte) bool {
+		keyBytes, valueBytes = gotKeyBytes, gotValueBytes
+		return false
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return keyBytes, valueBytes, nil
+}
+
+// ScanPrefix calls f for every record which encoded key starts with prefix
+// in byte order of encoded keys until f returns false.
+// With MapIndex and OrderedIndex only blocks containing matching records
+// are read. HashIndex and DiskIndex do not store keys, so every block
+// containing live records is read to find matching keys.
+func (db *Db) ScanPrefix(prefix []byte, f func(keyBytes, valueBytes []byte) (continueIteration bool)) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	records, err := db.rangeKeys(prefix, prefixEnd(prefix), 0)
+	if err != nil {
+		return err
+	}
+
+	return db.readRecords(records, f)
+}
+
+// CountPrefix returns number of records which encoded key starts with prefix.
+// With MapIndex and OrderedIndex blocks are not read, HashIndex and DiskIndex
+// read every block containing live records to get keys.
+func (db *Db) CountPrefix(prefix []byte) (int, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return 0, ErrClosed
+	}
+
+	records, err := db.rangeKeys(prefix, prefixEnd(prefix), 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(records), nil
+}
+
+// Keys calls f for every stored key in unspecified order until f returns false.
+// With MapIndex and OrderedIndex keys are read from key index and blocks
+// are not read. HashIndex and DiskIndex do not store keys, so every block
+// containing live records is read to get keys.
+// keyBytes must not be modified.
+func (db *Db) Keys(f func(keyBytes []byte) (continueIteration bool)) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	keysStored := true
+	err := db.keys.each(func(keyBytes []byte, _ coords) bool {
+		if keyBytes == nil {
+			keysStored = false
+			return false
+		}
+
+		return f(keyBytes)
+	})
+	if err != nil || keysStored {
+		return err
+	}
+
+	// index stores only key hashes
+	records, err := db.indexedKeys()
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if !f(record.keyBytes) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// SortedKeys calls f for every stored key in byte order until f returns false.
+// keyBytes must not be modified.
+func (db *Db) SortedKeys(f func(keyBytes []byte) (continueIteration bool)) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	records, err := db.rangeKeys(nil, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if !f(record.keyBytes) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// KeysInto decodes all stored keys and appends them to slice
+// pointed by slicePtr in unspecified order.
+func (db *Db) KeysInto(slicePtr interface{}) error {
+	slicePtrValue := reflect.ValueOf(slicePtr)
+	if slicePtrValue.Kind() != reflect.Ptr || slicePtrValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected pointer to slice, got %T", slicePtr)
+	}
+
+	sliceValue := slicePtrValue.Elem()
+	elemType := sliceValue.Type().Elem()
+
+	var err error
+	keysErr := db.Keys(func(keyBytes []byte) bool {
+		keyValue := reflect.New(elemType)
+
+		err = db.config.KeyCodec.Decode(keyBytes, keyValue.Interface())
+		if err != nil {
+			return false
+		}
+
+		sliceValue.Set(reflect.Append(sliceValue, keyValue.Elem()))
+		return true
+	})
+	if keysErr != nil {
+		return keysErr
+	}
+
+	return err
+}
+
+// prefixEnd returns smallest key which is greater than all keys with specified prefix.
+// Returns nil if there is no such key.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
+
+func (db *Db) encodeBound(bound interface{}) ([]byte, error) {
+	if bound == nil {
+		return nil, nil
+	}
+
+	return db.config.KeyCodec.Encode(bound)
+}
+
+// rangeKeys returns keys in [start, end) range in byte order.
+// nil start or end means unbounded range, limit <= 0 means no limit.
+func (db *Db) rangeKeys(start, end []byte, limit int) ([]keyCoords, error) {
+	inRange := func(keyBytes []byte) bool {
+		return (start == nil || bytes.Compare(keyBytes, start) >= 0) &&
+			(end == nil || bytes.Compare(keyBytes, end) < 0)
+	}
+
+	if ordered, ok := db.keys.(orderedIndex); ok {
+		var result []keyCoords
+		err := ordered.ascend(start, func(keyBytes []byte, c coords) bool {
+			if !inRange(keyBytes) || (limit > 0 && len(result) >= limit) {
+				return false
+			}
+
+			result = append(result, keyCoords{keyBytes, c})
+			return true
+		})
+
+		return result, err
+	}
+
+	if limit == 1 {
+		return db.minKey(inRange)
+	}
+
+	records, err := db.indexedKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	result := records[:0]
+	for _, record := range records {
+		if inRange(record.keyBytes) {
+			result = append(result, record)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].keyBytes, result[j].keyBytes) < 0
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// indexedKeys returns all indexed keys in unspecified order.
+// Keys of index which does not store them are read from blocks.
+func (db *Db) indexedKeys() ([]keyCoords, error) {
+	var records []keyCoords
+	keysStored := true
+
+	err := db.keys.each(func(keyBytes []byte, c coords) bool {
+		if keyBytes == nil {
+			keysStored = false
+		}
+
+		records = append(records, keyCoords{keyBytes, c})
+		return true
+	})
+	if err != nil || keysStored {
+		return records, err
+	}
+
+	sortByCoords(records)
+
+	var blockBytes []byte
+	for i := range records {
+		if i == 0 || records[i-1].c.blockNum != records[i].c.blockNum {
+			blockBytes, err = db.getBlockBytes(records[i].c.blockNum)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		_, records[i].keyBytes, _, err = readRecordAt(blockBytes, records[i].c.recordOffset)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+// minKey returns smallest key for which inRange returns true
+// in single pass over key index.
+func (db *Db) minKey(inRange func(keyBytes []byte) bool) ([]keyCoords, error) {
+	var result []keyCoords
+	consider := func(keyBytes []byte, c coords) {
+		if inRange(keyBytes) && (result == nil || bytes.Compare(keyBytes, result[0].keyBytes) < 0) {
+			result = []keyCoords{{keyBytes, c}}
+		}
+	}
+
+	keysStored := true
+	err := db.keys.each(func(keyBytes []byte, c coords) bool {
+		if keyBytes == nil {
+			keysStored = false
+			return false
+		}
+
+		consider(keyBytes, c)
+		return true
+	})
+	if err != nil || keysStored {
+		return result, err
+	}
+
+	// index stores only key hashes
+	records, err := db.indexedKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		consider(record.keyBytes, record.c)
+	}
+
+	return result, nil
+}
+
+// readRecordsCacheSize is max number of blocks cached by readRecords.
+const readRecordsCacheSize = 8
+
+// readRecords calls f for every specified record in given order
+// until f returns false. Up to readRecordsCacheSize recently read blocks
+// are cached, blocks without unread records are dropped from cache.
+func (db *Db) readRecords(records []keyCoords, f func(keyBytes, valueBytes []byte) bool) error {
+	remaining := make(map[int64]int) // [block number]number of unread records
+	for _, record := range records {
+		remaining[record.c.blockNum]++
+	}
+
+	var cache []cachedBlock // ordered from least to most recently used
+	for _, record := range records {
+		blockNum := record.c.blockNum
+
+		var blockBytes []byte
+		for i, block := range cache {
+			if block.num == blockNum {
+				blockBytes = block.bytes
+				cache = append(cache[:i], cache[i+1:]...)
+				break
+			}
+		}
+		if blockBytes == nil {
+			var err error
+			blockBytes, err = db.getBlockBytes(blockNum)
+			if err != nil {
+				return err
+			}
+			if len(cache) == readRecordsCacheSize {
+				cache = cache[1:]
+			}
+		}
+
+		remaining[blockNum]--
+		if remaining[blockNum] > 0 {
+			cache = append(cache, cachedBlock{blockNum, blockBytes})
+		}
+
+		action, keyBytes, valueBytes, err := readRecordAt(blockBytes, record.c.recordOffset)
+		if err != nil {
+			return err
+		}
+
+		if action != actionAdd {
+			return fmt.Errorf("expected %v action, got %v", actionAdd, action)
+		}
+
+		if !f(keyBytes, valueBytes) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func sortByCoords(records []keyCoords) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].c.blockNum != records[j].c.blockNum {
+			return records[i].c.blockNum < records[j].c.blockNum
+		}
+		return records[i].c.recordOffset < records[j].c.recordOffset
+	})
+}
@@ -0,0 +1,199 @@
+package zkv
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRange(t *testing.T) {
+	const filePath = "range.tmp"
+
+	for _, indexType := range []IndexType{MapIndex, HashIndex, DiskIndex, OrderedIndex} {
+		db, err := OpenWithConfig(filePath, &Config{BlockDataSize: 128, Index: indexType})
+		assert.NoError(t, err)
+
+		// write in reverse order to check sorting
+		for i := 99; i >= 0; i-- {
+			err = db.Set(fmt.Sprintf("key%03d", i), i)
+			assert.NoError(t, err)
+		}
+		err = db.Delete("key015")
+		assert.NoError(t, err)
+
+		var got []int
+		err = db.Range("key010", "key020", func(keyBytes, valueBytes []byte) bool {
+			var value int
+			err := Decode(valueBytes, &value)
+			assert.NoError(t, err)
+
+			got = append(got, value)
+			return true
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{10, 11, 12, 13, 14, 16, 17, 18, 19}, got, "index type", indexType)
+
+		var count int
+		err = db.Range(nil, nil, func(keyBytes, valueBytes []byte) bool {
+			count++
+			return true
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 99, count, "index type", indexType)
+
+		keyBytes, _, err := db.Seek("key05a")
+		assert.NoError(t, err)
+		var key string
+		err = Decode(keyBytes, &key)
+		assert.NoError(t, err)
+		assert.Equal(t, "key060", key, "index type", indexType)
+
+		_, _, err = db.Seek("key100")
+		assert.Equal(t, ErrNotFound, err)
+
+		err = db.Close()
+		assert.NoError(t, err)
+
+		err = os.Remove(filePath)
+		assert.NoError(t, err)
+	}
+}
+
+func TestRangeManyBlocks(t *testing.T) {
+	const filePath = "rangeManyBlocks.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, &Config{BlockDataSize: 64})
+	assert.NoError(t, err)
+
+	// key order does not match block order
+	rnd := rand.New(rand.NewSource(1))
+	for _, i := range rnd.Perm(1000) {
+		err = db.Set(fmt.Sprintf("key%04d", i), i)
+		assert.NoError(t, err)
+	}
+	assert.True(t, db.currentBlockNum > 10*readRecordsCacheSize)
+
+	var got []int
+	err = db.Range(nil, nil, func(keyBytes, valueBytes []byte) bool {
+		var value int
+		err := Decode(valueBytes, &value)
+		assert.NoError(t, err)
+
+		got = append(got, value)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Len(t, got, 1000)
+	for i, value := range got {
+		assert.Equal(t, i, value)
+	}
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestPrefixEnd(t *testing.T) {
+	assert.Equal(t, []byte("ab"), prefixEnd([]byte("aa")))
+	assert.Equal(t, []byte{2}, prefixEnd([]byte{1, 0xff}))
+	assert.Nil(t, prefixEnd([]byte{0xff, 0xff}))
+	assert.Nil(t, prefixEnd(nil))
+}
+
+func TestScanPrefix(t *testing.T) {
+	const filePath = "scanPrefix.tmp"
+
+	for _, indexType := range []IndexType{MapIndex, HashIndex, OrderedIndex} {
+		db, err := OpenWithConfig(filePath, &Config{BlockDataSize: 128, Index: indexType})
+		assert.NoError(t, err)
+
+		// keys have equal length, so they have equal encoded length prefix
+		for i := 0; i < 10; i++ {
+			err = db.Set(fmt.Sprintf("user/%d", i), i)
+			assert.NoError(t, err)
+			err = db.Set(fmt.Sprintf("grp/%02d", i), i)
+			assert.NoError(t, err)
+		}
+
+		keyBytes, err := Encode("user/0")
+		assert.NoError(t, err)
+		userPrefix := keyBytes[:len(keyBytes)-1]
+
+		var got []string
+		err = db.ScanPrefix(userPrefix, func(keyBytes, valueBytes []byte) bool {
+			var key string
+			err := Decode(keyBytes, &key)
+			assert.NoError(t, err)
+
+			got = append(got, key)
+			return true
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got, 10, "index type", indexType)
+		assert.Equal(t, "user/0", got[0])
+
+		count, err := db.CountPrefix(userPrefix)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, count)
+
+		count, err = db.CountPrefix(keyBytes)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		err = db.Close()
+		assert.NoError(t, err)
+
+		err = os.Remove(filePath)
+		assert.NoError(t, err)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	const filePath = "keys.tmp"
+
+	for _, indexType := range []IndexType{MapIndex, HashIndex, OrderedIndex} {
+		db, err := OpenWithConfig(filePath, &Config{BlockDataSize: 128, Index: indexType})
+		assert.NoError(t, err)
+
+		for i := 9; i >= 0; i-- {
+			err = db.Set(i, i)
+			assert.NoError(t, err)
+		}
+		err = db.Delete(5)
+		assert.NoError(t, err)
+
+		var keys []int
+		err = db.KeysInto(&keys)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 6, 7, 8, 9}, keys, "index type", indexType)
+
+		var count int
+		err = db.Keys(func(keyBytes []byte) bool {
+			count++
+			return count < 3
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		var prevKeyBytes []byte
+		err = db.SortedKeys(func(keyBytes []byte) bool {
+			assert.True(t, bytes.Compare(prevKeyBytes, keyBytes) < 0)
+			prevKeyBytes = keyBytes
+			return true
+		})
+		assert.NoError(t, err)
+
+		err = db.KeysInto(keys)
+		assert.Error(t, err)
+
+		err = db.Close()
+		assert.NoError(t, err)
+
+		err = os.Remove(filePath)
+		assert.NoError(t, err)
+	}
+}
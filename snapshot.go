@@ -0,0 +1,249 @@
+package zkv
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// snapshot is a view of key index frozen at the moment of creation.
+// Flushed blocks are immutable and write buffer is only appended, so it is
+// enough to remember previous coords of keys changed after snapshot creation.
+type snapshot struct {
+	db *Db
+
+	overlay  map[string]snapshotEntry // [key]state at the moment of snapshot creation
+	count    int
+	blockNum int64 // last block at the moment of snapshot creation
+}
+
+type snapshotEntry struct {
+	c      coords
+	exists bool
+}
+
+// newSnapshot registers new snapshot. db.mu must be locked.
+func (db *Db) newSnapshot() *snapshot {
+	s := &snapshot{
+		db:      db,
+		overlay:  make(map[string]snapshotEntry),
+		count:    db.keys.len(),
+		blockNum: db.currentBlockNum}
+
+	db.snapshots[s] = struct{}{}
+
+	return s
+}
+
+// releaseSnapshot stops tracking of changes for snapshot. db.mu must be locked.
+func (db *Db) releaseSnapshot(s *snapshot) {
+	delete(db.snapshots, s)
+}
+
+// preserveForSnapshots remembers current state of key for every active snapshot
+// which has not remembered it yet. Must be called before key index change.
+func (db *Db) preserveForSnapshots(keyBytes []byte) error {
+	if len(db.snapshots) == 0 {
+		return nil
+	}
+
+	c, exists, err := db.keys.get(keyBytes)
+	if err != nil {
+		return err
+	}
+
+	for s := range db.snapshots {
+		if _, ok := s.overlay[string(keyBytes)]; !ok {
+			s.overlay[string(keyBytes)] = snapshotEntry{c, exists}
+		}
+	}
+
+	return nil
+}
+
+// coords returns coords of key record at the moment of snapshot creation.
+// db.mu must be locked.
+func (s *snapshot) coords(keyBytes []byte) (coords, bool, error) {
+	if e, ok := s.overlay[string(keyBytes)]; ok {
+		return e.c, e.exists, nil
+	}
+
+	return s.db.keys.get(keyBytes)
+}
+
+// get returns value bytes of key at the moment of snapshot creation.
+func (s *snapshot) get(keyBytes []byte) ([]byte, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	if s.db.closed {
+		return nil, ErrClosed
+	}
+
+	c, exists, err := s.coords(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	blockBytes, err := s.db.getBlockBytes(c.blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	action, rKeyBytes, valueBytes, err := readRecordAt(blockBytes, c.recordOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	// index may store only key hashes
+	if !bytes.Equal(rKeyBytes, keyBytes) {
+		return nil, ErrNotFound
+	}
+
+	if action != actionAdd {
+		return nil, fmt.Errorf("expected %v action, got %v", actionAdd, action)
+	}
+
+	return valueBytes, nil
+}
+
+// iterate calls f for every record of snapshot in written order until f returns false.
+// Storage lock is held only while block is read, not during f calls.
+func (s *snapshot) iterate(f func(keyBytes, valueBytes []byte) bool) error {
+	// later blocks contain only records written after snapshot creation
+	for blockNum := int64(0); blockNum <= s.blockNum; blockNum++ {
+		records, err := s.blockRecords(blockNum)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if !f(record.keyBytes, record.valueBytes) {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+// blockRecords returns records of block which are live in snapshot.
+func (s *snapshot) blockRecords(blockNum int64) ([]record, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	if s.db.closed {
+		return nil, ErrClosed
+	}
+
+	blockBytes, err := s.db.getBlockBytes(blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := readBlockRecords(blockBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	live := records[:0]
+	for _, record := range records {
+		if record.action != actionAdd {
+			continue
+		}
+
+		c, exists, err := s.coords(record.keyBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists && c == (coords{blockNum: blockNum, recordOffset: record.offset}) {
+			live = append(live, record)
+		}
+	}
+
+	return live, nil
+}
+
+// Snapshot is a read only view of storage frozen at the moment of creation.
+// Later writes and flushes do not affect it. Storage lock is not held
+// between calls, so long reads do not block writers.
+// Snapshot must be closed after use.
+type Snapshot struct {
+	s      *snapshot
+	closed bool
+}
+
+// Snapshot returns read only view of current storage state.
+func (db *Db) Snapshot() (*Snapshot, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return nil, ErrClosed
+	}
+
+	return &Snapshot{s: db.newSnapshot()}, nil
+}
+
+// Get returns value of specified key.
+func (s *Snapshot) Get(key interface{}, valuePtr interface{}) error {
+	if s.closed {
+		return ErrClosed
+	}
+
+	keyBytes, err := s.s.db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return err
+	}
+
+	valueBytes, err := s.s.get(keyBytes)
+	if err != nil {
+		return err
+	}
+
+	return s.s.db.config.Codec.Decode(valueBytes, valuePtr)
+}
+
+// Iterate calls f for every record in written order until f returns false.
+// Storage lock is not held during f calls, so f may modify storage.
+func (s *Snapshot) Iterate(f func(keyBytes, valueBytes []byte) (continueIteration bool)) error {
+	if s.closed {
+		return ErrClosed
+	}
+
+	return s.s.iterate(f)
+}
+
+// Keys calls f for every key in written order until f returns false.
+func (s *Snapshot) Keys(f func(keyBytes []byte) (continueIteration bool)) error {
+	if s.closed {
+		return ErrClosed
+	}
+
+	return s.s.iterate(func(keyBytes, _ []byte) bool {
+		return f(keyBytes)
+	})
+}
+
+// Count returns number of records.
+func (s *Snapshot) Count() int {
+	return s.s.count
+}
+
+// Close releases snapshot.
+func (s *Snapshot) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	s.s.db.mu.Lock()
+	defer s.s.db.mu.Unlock()
+
+	s.s.db.releaseSnapshot(s.s)
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package zkv
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	const filePath = "snapshot.tmp"
+	defer os.Remove(filePath)
+
+	config := *defaultConfig
+	config.BlockDataSize = 64
+
+	db, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+
+	snap, err := db.Snapshot()
+	assert.NoError(t, err)
+
+	err = db.Set(0, 100)
+	assert.NoError(t, err)
+	err = db.Delete(1)
+	assert.NoError(t, err)
+	err = db.Set(10, 10)
+	assert.NoError(t, err)
+	err = db.Flush()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 10, snap.Count())
+
+	var got int
+	err = snap.Get(0, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, got)
+	err = snap.Get(1, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got)
+	err = snap.Get(10, &got)
+	assert.Equal(t, ErrNotFound, err)
+
+	// storage can be modified inside iteration
+	var keys, values []int
+	err = snap.Iterate(func(keyBytes, valueBytes []byte) bool {
+		var key, value int
+		err := db.config.KeyCodec.Decode(keyBytes, &key)
+		assert.NoError(t, err)
+		err = db.config.Codec.Decode(valueBytes, &value)
+		assert.NoError(t, err)
+		keys, values = append(keys, key), append(values, value)
+
+		err = db.Set(key+100, value)
+		assert.NoError(t, err)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, keys)
+	assert.Equal(t, keys, values)
+
+	count := 0
+	err = snap.Keys(func(keyBytes []byte) bool {
+		count++
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, count)
+
+	err = snap.Close()
+	assert.NoError(t, err)
+	assert.Empty(t, db.snapshots)
+
+	err = snap.Get(0, &got)
+	assert.Equal(t, ErrClosed, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
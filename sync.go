@@ -0,0 +1,46 @@
+package zkv
+
+// SyncMode sets when written data is fsynced to disk.
+type SyncMode int8
+
+const (
+	// SyncNone leaves fsync to operating system
+	SyncNone SyncMode = iota
+
+	// SyncOnFlush fsyncs file after every written block
+	SyncOnFlush
+
+	// SyncAlways flushes and fsyncs every write, so every write is stored
+	// as separate block
+	SyncAlways
+)
+
+// Sync flushes write buffer and fsyncs storage file.
+func (db *Db) Sync() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	if db.config.ReadOnly {
+		return nil
+	}
+
+	err := db.flush()
+	if err != nil {
+		return err
+	}
+
+	return db.w.Sync()
+}
+
+// syncWrite makes completed write durable in SyncAlways mode.
+func (db *Db) syncWrite() error {
+	if db.config.Sync != SyncAlways {
+		return nil
+	}
+
+	return db.flush()
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package zkv
+
+// syncDir does nothing: directories can't be fsynced on this platform.
+func syncDir(path string) error {
+	return nil
+}
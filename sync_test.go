@@ -0,0 +1,70 @@
+package zkv
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncAlways(t *testing.T) {
+	const filePath = "syncAlways.tmp"
+	defer os.Remove(filePath)
+
+	config := *defaultConfig
+	config.Sync = SyncAlways
+
+	db, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+
+	err = db.Set(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, db.buf.Len())
+
+	b := db.NewBatch()
+	err = b.Set(2, 2)
+	assert.NoError(t, err)
+	err = b.Delete(1)
+	assert.NoError(t, err)
+	err = db.Write(b)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, db.buf.Len())
+
+	// written data is visible without closing
+	readerConfig := *defaultConfig
+	readerConfig.ReadOnly = true
+	readerConfig.Lock = LockNone
+	r, err := OpenWithConfig(filePath, &readerConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r.Count())
+	err = r.Close()
+	assert.NoError(t, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestSync(t *testing.T) {
+	const filePath = "sync.tmp"
+	defer os.Remove(filePath)
+
+	config := *defaultConfig
+	config.Sync = SyncOnFlush
+
+	db, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+
+	err = db.Set(1, 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, db.buf.Len())
+
+	err = db.Sync()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, db.buf.Len())
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	err = db.Sync()
+	assert.Equal(t, ErrClosed, err)
+}
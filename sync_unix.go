@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package zkv
+
+import "os"
+
+// syncDir fsyncs directory so created file entry is durable.
+func syncDir(path string) error {
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	err = d.Sync()
+	if err != nil {
+		d.Close()
+		return err
+	}
+
+	return d.Close()
+}
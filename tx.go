@@ -0,0 +1,213 @@
+package zkv
+
+// Tx is a transaction with snapshot isolation.
+// Reads see storage state at the moment of Begin call plus own writes,
+// writes are buffered until Commit.
+// Tx is not safe for concurrent use.
+type Tx struct {
+	db   *Db
+	snap *snapshot
+
+	writes  []batchRecord
+	written map[string]int // [key]index of last write of key in writes
+
+	done bool
+}
+
+// Begin starts new transaction.
+// Transaction must be finished with Commit or Rollback.
+func (db *Db) Begin() (*Tx, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return nil, ErrClosed
+	}
+
+	return &Tx{
+		db:      db,
+		snap:    db.newSnapshot(),
+		written: make(map[string]int)}, nil
+}
+
+// Get returns value of specified key.
+func (tx *Tx) Get(key interface{}, valuePtr interface{}) error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	keyBytes, err := tx.db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return err
+	}
+
+	var valueBytes []byte
+	if i, ok := tx.written[string(keyBytes)]; ok {
+		if tx.writes[i].action == actionDelete {
+			return ErrNotFound
+		}
+		valueBytes = tx.writes[i].valueBytes
+	} else {
+		valueBytes, err = tx.snap.get(keyBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.db.config.Codec.Decode(valueBytes, valuePtr)
+}
+
+// Set saves value for specified key on commit.
+func (tx *Tx) Set(key, value interface{}) error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	keyBytes, err := tx.db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return err
+	}
+
+	valueBytes, err := tx.db.config.Codec.Encode(value)
+	if err != nil {
+		return err
+	}
+
+	tx.write(batchRecord{actionAdd, keyBytes, valueBytes})
+
+	return nil
+}
+
+// Delete deletes value of specified key on commit.
+func (tx *Tx) Delete(key interface{}) error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	keyBytes, err := tx.db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return err
+	}
+
+	tx.write(batchRecord{actionDelete, keyBytes, nil})
+
+	return nil
+}
+
+func (tx *Tx) write(record batchRecord) {
+	if i, ok := tx.written[string(record.keyBytes)]; ok {
+		tx.writes[i] = record
+		return
+	}
+
+	tx.written[string(record.keyBytes)] = len(tx.writes)
+	tx.writes = append(tx.writes, record)
+}
+
+// Iterate calls f for every record visible in transaction until f returns false.
+// Records of snapshot are visited in written order, then records set in
+// transaction in order of first write.
+// Storage lock is not held during f calls.
+func (tx *Tx) Iterate(f func(keyBytes, valueBytes []byte) (continueIteration bool)) error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	stopped := false
+	err := tx.snap.iterate(func(keyBytes, valueBytes []byte) bool {
+		if _, ok := tx.written[string(keyBytes)]; ok {
+			return true
+		}
+
+		stopped = !f(keyBytes, valueBytes)
+		return !stopped
+	})
+	if err != nil || stopped {
+		return err
+	}
+
+	for _, record := range tx.writes {
+		if record.action != actionAdd {
+			continue
+		}
+
+		if !f(record.keyBytes, record.valueBytes) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Commit atomically applies writes of transaction.
+// Returns ErrConflict without applying anything if any written key
+// was changed by someone else after Begin call.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+
+	err := tx.validate()
+
+	// released before write, so own writes are not copied to snapshot
+	tx.db.releaseSnapshot(tx.snap)
+
+	if err != nil || len(tx.writes) == 0 {
+		return err
+	}
+
+	return tx.db.writeBatch(tx.writes)
+}
+
+// validate checks that transaction can be committed. db.mu must be locked.
+func (tx *Tx) validate() error {
+	if tx.db.closed {
+		return ErrClosed
+	}
+
+	if len(tx.writes) == 0 {
+		return nil
+	}
+
+	if tx.db.config.ReadOnly {
+		return errReadOnly
+	}
+
+	for _, record := range tx.writes {
+		snapCoords, snapExists, err := tx.snap.coords(record.keyBytes)
+		if err != nil {
+			return err
+		}
+
+		c, exists, err := tx.db.keys.get(record.keyBytes)
+		if err != nil {
+			return err
+		}
+
+		if exists != snapExists || (exists && c != snapCoords) {
+			return ErrConflict
+		}
+	}
+
+	return nil
+}
+
+// Rollback discards writes of transaction.
+// Rollback after Commit does nothing.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+
+	tx.db.releaseSnapshot(tx.snap)
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package zkv
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxIsolation(t *testing.T) {
+	const filePath = "txIsolation.tmp"
+	defer os.Remove(filePath)
+
+	config := *defaultConfig
+	config.BlockDataSize = 64
+
+	db, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+
+	// changes after Begin are not visible in transaction
+	err = db.Set(1, 100)
+	assert.NoError(t, err)
+	err = db.Delete(2)
+	assert.NoError(t, err)
+	err = db.Set(10, 10)
+	assert.NoError(t, err)
+	err = db.Flush()
+	assert.NoError(t, err)
+
+	var got int
+	err = tx.Get(1, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got)
+	err = tx.Get(2, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, got)
+	err = tx.Get(10, &got)
+	assert.Equal(t, ErrNotFound, err)
+
+	// own writes are visible
+	err = tx.Set(3, 300)
+	assert.NoError(t, err)
+	err = tx.Delete(4)
+	assert.NoError(t, err)
+	err = tx.Set(20, 20)
+	assert.NoError(t, err)
+
+	err = tx.Get(3, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 300, got)
+	err = tx.Get(4, &got)
+	assert.Equal(t, ErrNotFound, err)
+
+	var keys []int
+	err = tx.Iterate(func(keyBytes, valueBytes []byte) bool {
+		var key int
+		err := db.config.KeyCodec.Decode(keyBytes, &key)
+		assert.NoError(t, err)
+		keys = append(keys, key)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 5, 6, 7, 8, 9, 3, 20}, keys)
+
+	// not changed by transaction until commit
+	err = db.Get(3, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, got)
+
+	err = tx.Commit()
+	assert.NoError(t, err)
+
+	err = db.Get(3, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 300, got)
+	err = db.Get(4, &got)
+	assert.Equal(t, ErrNotFound, err)
+	err = db.Get(20, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, got)
+
+	err = tx.Get(3, &got)
+	assert.Equal(t, ErrTxDone, err)
+	err = tx.Rollback()
+	assert.NoError(t, err)
+
+	assert.Empty(t, db.snapshots)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestTxConflict(t *testing.T) {
+	const filePath = "txConflict.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	err = db.Set(1, 1)
+	assert.NoError(t, err)
+
+	tx1, err := db.Begin()
+	assert.NoError(t, err)
+	tx2, err := db.Begin()
+	assert.NoError(t, err)
+
+	err = tx1.Set(1, 10)
+	assert.NoError(t, err)
+	err = tx1.Set(2, 10)
+	assert.NoError(t, err)
+	err = tx2.Set(1, 20)
+	assert.NoError(t, err)
+
+	err = tx1.Commit()
+	assert.NoError(t, err)
+	err = tx2.Commit()
+	assert.Equal(t, ErrConflict, err)
+
+	var got int
+	err = db.Get(1, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, got)
+
+	// writes of other keys do not conflict
+	tx3, err := db.Begin()
+	assert.NoError(t, err)
+	err = db.Set(3, 3)
+	assert.NoError(t, err)
+	err = tx3.Delete(1)
+	assert.NoError(t, err)
+	err = tx3.Commit()
+	assert.NoError(t, err)
+
+	err = db.Get(1, &got)
+	assert.Equal(t, ErrNotFound, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestTxRollback(t *testing.T) {
+	const filePath = "txRollback.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	err = tx.Set(1, 1)
+	assert.NoError(t, err)
+	err = tx.Rollback()
+	assert.NoError(t, err)
+
+	err = tx.Commit()
+	assert.Equal(t, ErrTxDone, err)
+	assert.Equal(t, 0, db.Count())
+	assert.Empty(t, db.snapshots)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
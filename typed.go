@@ -0,0 +1,97 @@
+package zkv
+
+import "iter"
+
+// Typed provides type safe access to storage with keys of type K
+// and values of type V.
+type Typed[K, V any] struct {
+	db *Db
+}
+
+// NewTyped returns type safe wrapper of db.
+func NewTyped[K, V any](db *Db) *Typed[K, V] {
+	return &Typed[K, V]{db: db}
+}
+
+// Get returns value of specified key.
+func (t *Typed[K, V]) Get(key K) (V, error) {
+	var value V
+	err := t.db.Get(key, &value)
+
+	return value, err
+}
+
+// Set saves value for specified key.
+func (t *Typed[K, V]) Set(key K, value V) error {
+	return t.db.Set(key, value)
+}
+
+// Delete deletes value of specified key.
+func (t *Typed[K, V]) Delete(key K) error {
+	return t.db.Delete(key)
+}
+
+// All returns iterator over all records in written order.
+// Records are read from snapshot created when loop starts, so loop body
+// can modify storage: records written inside loop are not visited.
+// Iteration stops on first error, which is stored to err.
+// err may be nil if errors are not needed: iteration just stops on them.
+func (t *Typed[K, V]) All(err *error) iter.Seq2[K, V] {
+	if err == nil {
+		err = new(error)
+	}
+
+	return func(yield func(K, V) bool) {
+		snap, snapErr := t.db.Snapshot()
+		*err = snapErr
+		if snapErr != nil {
+			return
+		}
+		defer snap.Close()
+
+		iterErr := snap.Iterate(func(keyBytes, valueBytes []byte) bool {
+			var key K
+			decodeErr := t.db.config.KeyCodec.Decode(keyBytes, &key)
+			if decodeErr != nil {
+				*err = decodeErr
+				return false
+			}
+
+			var value V
+			decodeErr = t.db.config.Codec.Decode(valueBytes, &value)
+			if decodeErr != nil {
+				*err = decodeErr
+				return false
+			}
+
+			return yield(key, value)
+		})
+		if iterErr != nil {
+			*err = iterErr
+		}
+	}
+}
+
+// Keys returns iterator over all keys in unspecified order.
+// Keys are collected before first step, so loop body can modify storage.
+// Iteration stops on first error, which is stored to err.
+// err may be nil if errors are not needed: iteration just stops on them.
+func (t *Typed[K, V]) Keys(err *error) iter.Seq[K] {
+	if err == nil {
+		err = new(error)
+	}
+
+	return func(yield func(K) bool) {
+		var keys []K
+		*err = t.db.KeysInto(&keys)
+		if *err != nil {
+			return
+		}
+
+		for _, key := range keys {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
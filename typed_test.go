@@ -0,0 +1,134 @@
+package zkv
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTyped(t *testing.T) {
+	const filePath = "typed.tmp"
+	defer os.Remove(filePath)
+
+	type User struct {
+		Name string
+		Age  int
+	}
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	users := NewTyped[string, User](db)
+
+	err = users.Set("alice", User{"Alice", 30})
+	assert.NoError(t, err)
+	err = users.Set("bob", User{"Bob", 25})
+	assert.NoError(t, err)
+	err = users.Set("carol", User{"Carol", 40})
+	assert.NoError(t, err)
+
+	user, err := users.Get("bob")
+	assert.NoError(t, err)
+	assert.Equal(t, User{"Bob", 25}, user)
+
+	_, err = users.Get("dave")
+	assert.Equal(t, ErrNotFound, err)
+
+	// records written inside loop are not visited
+	var iterErr error
+	var ages []int
+	for key, user := range users.All(&iterErr) {
+		ages = append(ages, user.Age)
+
+		user.Age++
+		err = users.Set(key, user)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, iterErr)
+	assert.Equal(t, []int{30, 25, 40}, ages)
+
+	user, err = users.Get("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, 31, user.Age)
+
+	// storage can be modified inside loop
+	var names []string
+	for key, user := range users.All(&iterErr) {
+		names = append(names, user.Name)
+
+		err = users.Delete(key)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, iterErr)
+	assert.Equal(t, []string{"Alice", "Bob", "Carol"}, names)
+	assert.Equal(t, 0, db.Count())
+
+	err = users.Set("dave", User{"Dave", 50})
+	assert.NoError(t, err)
+
+	var keys []string
+	for key := range users.Keys(&iterErr) {
+		keys = append(keys, key)
+	}
+	assert.NoError(t, iterErr)
+	assert.Equal(t, []string{"dave"}, keys)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestTypedErr(t *testing.T) {
+	const filePath = "typedErr.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	values := NewTyped[string, int](db)
+
+	err = values.Set("a", 1)
+	assert.NoError(t, err)
+
+	keyBytes, err := db.config.KeyCodec.Encode("b")
+	assert.NoError(t, err)
+	err = db.SetRaw(keyBytes, bytes.Repeat([]byte{0xff}, 11)) // value can't be decoded
+	assert.NoError(t, err)
+
+	// every loop has own error
+	var outerErr, innerErr error
+	for range values.All(&outerErr) {
+		for range values.All(&innerErr) {
+		}
+		assert.Error(t, innerErr)
+		break
+	}
+	assert.NoError(t, outerErr)
+
+	// without err iteration just stops on error
+	n := 0
+	for range values.All(nil) {
+		n++
+	}
+	assert.Equal(t, 1, n)
+
+	err = db.DeleteRaw(keyBytes)
+	assert.NoError(t, err)
+
+	n = 0
+	for range values.All(&innerErr) {
+		n++
+	}
+	assert.NoError(t, innerErr)
+	assert.Equal(t, 1, n)
+
+	n = 0
+	for range values.Keys(nil) {
+		n++
+	}
+	assert.Equal(t, 1, n)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,142 @@
+package zkv
+
+import "bytes"
+
+// Has reports whether specified key exists.
+func (db *Db) Has(key interface{}) (bool, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return false, ErrClosed
+	}
+
+	keyBytes, err := db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return false, err
+	}
+
+	return db.has(keyBytes)
+}
+
+// SetIfAbsent saves value for specified key only if key does not exists.
+// Returns true if value was saved.
+func (db *Db) SetIfAbsent(key interface{}, value interface{}) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return false, ErrClosed
+	}
+
+	if db.config.ReadOnly {
+		return false, errReadOnly
+	}
+
+	keyBytes, err := db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return false, err
+	}
+
+	exists, err := db.has(keyBytes)
+	if err != nil || exists {
+		return false, err
+	}
+
+	valueBytes, err := db.config.Codec.Encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	return true, db.writeRecord(actionAdd, keyBytes, valueBytes)
+}
+
+// CompareAndSwap saves new value for specified key only if current value
+// is equal to old value (compared in encoded form).
+// Returns true if value was saved.
+func (db *Db) CompareAndSwap(key interface{}, old interface{}, new interface{}) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return false, ErrClosed
+	}
+
+	if db.config.ReadOnly {
+		return false, errReadOnly
+	}
+
+	keyBytes, err := db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return false, err
+	}
+
+	oldBytes, err := db.config.Codec.Encode(old)
+	if err != nil {
+		return false, err
+	}
+
+	currentBytes, err := db.getBytes(keyBytes)
+	if err == ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if !bytes.Equal(currentBytes, oldBytes) {
+		return false, nil
+	}
+
+	newBytes, err := db.config.Codec.Encode(new)
+	if err != nil {
+		return false, err
+	}
+
+	return true, db.writeRecord(actionAdd, keyBytes, newBytes)
+}
+
+// Update atomically replaces value of specified key with value returned by f.
+// f receives current encoded value and must return new encoded value.
+// Key is deleted if f returns true deleteKey. Nothing is changed if f returns error.
+// f must not call storage methods.
+func (db *Db) Update(key interface{}, f func(oldValueBytes []byte, exists bool) (newValueBytes []byte, deleteKey bool, err error)) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	if db.config.ReadOnly {
+		return errReadOnly
+	}
+
+	keyBytes, err := db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return err
+	}
+
+	oldBytes, err := db.getBytes(keyBytes)
+	exists := err == nil
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	newBytes, deleteKey, err := f(oldBytes, exists)
+	if err != nil {
+		return err
+	}
+
+	if deleteKey {
+		return db.deleteBytes(keyBytes)
+	}
+
+	return db.writeRecord(actionAdd, keyBytes, newBytes)
+}
+
+// has reports whether specified key exists.
+func (db *Db) has(keyBytes []byte) (bool, error) {
+	_, exists, err := db.keys.get(keyBytes)
+
+	return exists, err
+}
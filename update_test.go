@@ -0,0 +1,139 @@
+package zkv
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasAndSetIfAbsent(t *testing.T) {
+	const filePath = "setIfAbsent.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	exists, err := db.Has(1)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	saved, err := db.SetIfAbsent(1, "first")
+	assert.NoError(t, err)
+	assert.True(t, saved)
+
+	saved, err = db.SetIfAbsent(1, "second")
+	assert.NoError(t, err)
+	assert.False(t, saved)
+
+	exists, err = db.Has(1)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	var got string
+	err = db.Get(1, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", got)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	const filePath = "compareAndSwap.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	swapped, err := db.CompareAndSwap(1, 1, 2)
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+
+	err = db.Set(1, 1)
+	assert.NoError(t, err)
+
+	swapped, err = db.CompareAndSwap(1, 5, 2)
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = db.CompareAndSwap(1, 1, 2)
+	assert.NoError(t, err)
+	assert.True(t, swapped)
+
+	var got int
+	err = db.Get(1, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, got)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestUpdate(t *testing.T) {
+	const filePath = "update.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	increment := func(oldValueBytes []byte, exists bool) ([]byte, bool, error) {
+		var counter int
+		if exists {
+			err := Decode(oldValueBytes, &counter)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+
+		newValueBytes, err := Encode(counter + 1)
+		return newValueBytes, false, err
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				err := db.Update("counter", increment)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var got int
+	err = db.Get("counter", &got)
+	assert.NoError(t, err)
+	assert.Equal(t, 800, got)
+
+	err = db.Update("counter", func(oldValueBytes []byte, exists bool) ([]byte, bool, error) {
+		return nil, true, nil
+	})
+	assert.NoError(t, err)
+
+	exists, err := db.Has("counter")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// empty value is kept
+	keyBytes, err := Encode("empty")
+	assert.NoError(t, err)
+	err = db.SetRaw(keyBytes, []byte{})
+	assert.NoError(t, err)
+	err = db.Update("empty", func(oldValueBytes []byte, exists bool) ([]byte, bool, error) {
+		assert.True(t, exists)
+		return oldValueBytes, false, nil
+	})
+	assert.NoError(t, err)
+
+	valueBytes, err := db.GetRaw(keyBytes)
+	assert.NoError(t, err)
+	assert.Empty(t, valueBytes)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,99 @@
+package zkv
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Event describes applied key change.
+type Event struct {
+	Action Action
+	Key    []byte // encoded key
+	Value  []byte // encoded value, nil for ActionDelete
+}
+
+type watcher struct {
+	prefix []byte
+	ch     chan Event
+}
+
+// Watch returns channel of events for every applied change of keys which
+// encoded form starts with prefix. Empty prefix matches all keys.
+// Channel buffer size is set by Config.WatchBufferSize. If buffer is full,
+// consumer is considered too slow: its channel is closed without sending
+// the event, so consumer must resynchronize and call Watch again.
+// Channel is also closed by cancel call and storage closing.
+func (db *Db) Watch(prefix []byte) (events <-chan Event, cancel func()) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	w := &watcher{
+		prefix: append([]byte(nil), prefix...),
+		ch:     make(chan Event, db.config.WatchBufferSize)}
+
+	if db.closed {
+		close(w.ch)
+		return w.ch, func() {}
+	}
+
+	db.watchers[w] = struct{}{}
+
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() {
+			db.mu.Lock()
+			defer db.mu.Unlock()
+
+			db.removeWatcher(w)
+		})
+	}
+
+	return w.ch, cancel
+}
+
+// notify sends event to matching watchers. db.mu must be locked.
+func (db *Db) notify(action Action, keyBytes, valueBytes []byte) {
+	if len(db.watchers) == 0 {
+		return
+	}
+
+	var event *Event
+	for w := range db.watchers {
+		if !bytes.HasPrefix(keyBytes, w.prefix) {
+			continue
+		}
+
+		if event == nil {
+			// bytes may be reused by caller
+			event = &Event{
+				Action: action,
+				Key:    append([]byte(nil), keyBytes...)}
+			if valueBytes != nil {
+				event.Value = append([]byte(nil), valueBytes...)
+			}
+		}
+
+		select {
+		case w.ch <- *event:
+		default:
+			db.removeWatcher(w)
+		}
+	}
+}
+
+// removeWatcher unsubscribes watcher and closes its channel. db.mu must be locked.
+func (db *Db) removeWatcher(w *watcher) {
+	if _, exists := db.watchers[w]; !exists {
+		return
+	}
+
+	delete(db.watchers, w)
+	close(w.ch)
+}
+
+// closeWatchers closes channels of all watchers. db.mu must be locked.
+func (db *Db) closeWatchers() {
+	for w := range db.watchers {
+		db.removeWatcher(w)
+	}
+}
@@ -0,0 +1,79 @@
+package zkv
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	const filePath = "watch.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	all, cancelAll := db.Watch(nil)
+	users, cancelUsers := db.Watch([]byte("user:"))
+	defer cancelUsers()
+
+	err = db.SetRaw([]byte("user:1"), []byte("alice"))
+	assert.NoError(t, err)
+	err = db.SetRaw([]byte("order:1"), []byte("book"))
+	assert.NoError(t, err)
+	err = db.DeleteRaw([]byte("user:1"))
+	assert.NoError(t, err)
+	err = db.DeleteRaw([]byte("user:2")) // missing key is not changed
+	assert.NoError(t, err)
+
+	assert.Equal(t, Event{ActionSet, []byte("user:1"), []byte("alice")}, <-all)
+	assert.Equal(t, Event{ActionSet, []byte("order:1"), []byte("book")}, <-all)
+	assert.Equal(t, Event{ActionDelete, []byte("user:1"), nil}, <-all)
+	assert.Len(t, all, 0)
+
+	assert.Equal(t, Event{ActionSet, []byte("user:1"), []byte("alice")}, <-users)
+	assert.Equal(t, Event{ActionDelete, []byte("user:1"), nil}, <-users)
+	assert.Len(t, users, 0)
+
+	cancelAll()
+	cancelAll()
+	_, ok := <-all
+	assert.False(t, ok)
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	_, ok = <-users
+	assert.False(t, ok)
+}
+
+func TestWatchSlowConsumer(t *testing.T) {
+	const filePath = "watchSlowConsumer.tmp"
+	defer os.Remove(filePath)
+
+	config := *defaultConfig
+	config.WatchBufferSize = 2
+
+	db, err := OpenWithConfig(filePath, &config)
+	assert.NoError(t, err)
+
+	events, cancel := db.Watch(nil)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+
+	// buffered events are delivered before channel closing
+	n := 0
+	for range events {
+		n++
+	}
+	assert.Equal(t, 2, n)
+	assert.Empty(t, db.watchers)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
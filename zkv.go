@@ -5,8 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 type coords struct {
@@ -16,15 +21,35 @@ type coords struct {
 
 // Db represents key/value storage.
 type Db struct {
-	filePath  string
+	filePath string
+	f        *os.File // read handle, used for positional reads only
+	w        *os.File // append handle, nil for read only storage
+	mmap     *mapping // nil if Config.Mmap is not set
+
+	fileVersion int8 // version from file header
+
+	dataOffset int64 // file offset of first block
+	dataEnd    int64 // file offset after last read or written block
+
+	lastBlockBytes []byte // data of last read block
+
 	buf       bytes.Buffer
-	keys      map[string]coords // [key]block number + record offset
-	blockInfo map[int64]int64   // [block number]file offset
+	keys      index           // [key]block number + record offset
+	blockInfo map[int64]int64 // [block number]file offset
 
 	currentBlockNum int64
 
+	replay replayState // state of records replay from file
+
+	stopRefresh chan struct{} // closed on Close, nil if Config.RefreshInterval is not set
+
+	snapshots map[*snapshot]struct{} // active snapshots
+	watchers  map[*watcher]struct{}  // active Watch subscriptions
+
 	config Config
 
+	closed bool
+
 	mu sync.RWMutex
 }
 
@@ -48,38 +73,61 @@ func open(path string, config *Config) (*Db, error) {
 		return nil, errors.New("trying to create new readonly storage")
 	}
 
-	var f *os.File
-	var err error
-
 	if newDb {
-		err = initDb(path, config)
+		err := initDb(path, config)
 		if err != nil {
 			return nil, fmt.Errorf("init file: %v", err)
 		}
 	}
 
-	f, err = os.OpenFile(path, os.O_RDONLY, 0644)
+	f, err := os.OpenFile(path, os.O_RDONLY, 0644)
 	if err != nil {
 		return nil, fmt.Errorf("open storage file: %v", err)
 	}
-	defer f.Close()
 
 	db := &Db{
 		filePath:  path,
-		keys:      make(map[string]coords),
-		blockInfo: make(map[int64]int64)}
+		f:         f,
+		blockInfo: make(map[int64]int64),
+		snapshots: make(map[*snapshot]struct{}),
+		watchers:  make(map[*watcher]struct{})}
 
-	header, err := readHeader(f)
+	err = db.init(config)
 	if err != nil {
-		return nil, fmt.Errorf("read header: %v", err)
+		db.closeFiles()
+		return nil, err
 	}
 
+	return db, nil
+}
+
+func (db *Db) init(config *Config) error {
+	header, err := readHeader(io.NewSectionReader(db.f, 0, maxHeaderLength))
+	if err != nil {
+		return fmt.Errorf("read header: %v", err)
+	}
+	db.fileVersion = header.version
+	db.dataOffset = header.length()
+	db.dataEnd = db.dataOffset
+
 	compressor, exists := availableCompressors[header.compressorId]
 	if !exists {
-		return nil, fmt.Errorf("unknown compressor id = %d", header.compressorId)
+		return fmt.Errorf("unknown compressor id = %d", header.compressorId)
 	}
 	db.config.Compressor = compressor
 
+	codec, exists := availableCodecs[header.codecId]
+	if !exists {
+		return fmt.Errorf("unknown codec id = %d", header.codecId)
+	}
+	db.config.Codec = codec
+
+	keyCodec, exists := availableCodecs[header.keyCodecId]
+	if !exists {
+		return fmt.Errorf("unknown key codec id = %d", header.keyCodecId)
+	}
+	db.config.KeyCodec = keyCodec
+
 	if config != nil && config.BlockDataSize > 0 {
 		db.config.BlockDataSize = config.BlockDataSize
 	} else {
@@ -90,28 +138,122 @@ func open(path string, config *Config) (*Db, error) {
 		db.config.ReadOnly = config.ReadOnly
 	}
 
-	if config != nil && config.Compressor != nil && db.config.Compressor.Id() != config.Compressor.Id() {
-		return nil, fmt.Errorf("can't change compressor to %d on existing storage with compressor %d", config.Compressor.Id(), db.config.Compressor.Id())
+	if config != nil && config.Mmap {
+		db.config.Mmap = config.Mmap
 	}
 
-	err = db.readAllBlocks()
+	if config != nil {
+		db.config.Index = config.Index
+	}
+
+	if config != nil && config.IndexCacheSize > 0 {
+		db.config.IndexCacheSize = config.IndexCacheSize
+	} else {
+		db.config.IndexCacheSize = defaultConfig.IndexCacheSize
+	}
+
+	if config != nil && config.WatchBufferSize > 0 {
+		db.config.WatchBufferSize = config.WatchBufferSize
+	} else {
+		db.config.WatchBufferSize = defaultConfig.WatchBufferSize
+	}
+
+	db.keys, err = newIndex(db.filePath, db.config)
 	if err != nil {
-		return nil, fmt.Errorf("read stored records: %v", err)
+		return err
+	}
+
+	if config != nil && config.Compressor != nil && db.config.Compressor.Id() != config.Compressor.Id() {
+		return fmt.Errorf("can't change compressor to %d on existing storage with compressor %d", config.Compressor.Id(), db.config.Compressor.Id())
+	}
+
+	if config != nil && config.Codec != nil && db.config.Codec.Id() != config.Codec.Id() {
+		return fmt.Errorf("can't change codec to %d on existing storage with codec %d", config.Codec.Id(), db.config.Codec.Id())
+	}
+
+	if config != nil && config.KeyCodec != nil && db.config.KeyCodec.Id() != config.KeyCodec.Id() {
+		return fmt.Errorf("can't change key codec to %d on existing storage with key codec %d", config.KeyCodec.Id(), db.config.KeyCodec.Id())
+	}
+
+	if config != nil {
+		db.config.Lock = config.Lock
+		db.config.Sync = config.Sync
+	}
+
+	err = lockFile(db.f, db.config.Lock, !db.config.ReadOnly)
+	if err == ErrLocked {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("lock storage file: %v", err)
+	}
+
+	if !db.config.ReadOnly {
+		db.w, err = os.OpenFile(db.filePath, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("open storage file for writing: %v", err)
+		}
+	}
+
+	if db.config.Mmap {
+		stat, err := db.f.Stat()
+		if err != nil {
+			return err
+		}
+
+		db.mmap, err = newMapping(db.f.Fd(), stat.Size())
+		if err != nil {
+			return err
+		}
 	}
 
-	err = db.restoreWriteBuffer()
+	if config != nil && config.RefreshInterval > 0 {
+		db.config.RefreshInterval = config.RefreshInterval
+	}
+
+	err = db.readBlocks()
 	if err != nil {
-		return nil, fmt.Errorf("restoreWriteBuffer: %v", err)
+		return fmt.Errorf("read stored records: %v", err)
 	}
 
-	return db, nil
+	// read only storage reads appended blocks by Refresh, so last block is kept as is
+	if !db.config.ReadOnly {
+		err = db.restoreWriteBuffer()
+		if err != nil {
+			return fmt.Errorf("restoreWriteBuffer: %v", err)
+		}
+		db.lastBlockBytes = nil
+	}
+
+	// records written after incomplete batch must not be counted as its records
+	if db.replay.active && !db.config.ReadOnly {
+		err = db.writeEmptyBatch()
+		if err != nil {
+			return fmt.Errorf("discard incomplete batch: %v", err)
+		}
+	}
+
+	if db.config.ReadOnly && db.config.RefreshInterval > 0 {
+		db.stopRefresh = make(chan struct{})
+		go db.autoRefresh(db.config.RefreshInterval, db.stopRefresh)
+	}
+
+	return nil
 }
 
+// initDb creates storage file with header. Header is written to temporary
+// file which is then linked to filePath, so concurrent openers never see
+// file without header and only one of concurrent creators wins.
 func initDb(filePath string, config *Config) error {
-	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	dir, name := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := os.CreateTemp(dir, name+".*.tmp")
 	if err != nil {
 		return fmt.Errorf("create file: %v", err)
 	}
+	defer os.Remove(f.Name())
 
 	var compressor Compressor
 	if config == nil || config.Compressor == nil {
@@ -120,69 +262,106 @@ func initDb(filePath string, config *Config) error {
 		compressor = config.Compressor
 	}
 
-	err = writeHeader(f, compressor.Id())
+	var codec Codec
+	if config == nil || config.Codec == nil {
+		codec = defaultConfig.Codec
+	} else {
+		codec = config.Codec
+	}
+
+	keyCodec := codec
+	if config != nil && config.KeyCodec != nil {
+		keyCodec = config.KeyCodec
+	}
+
+	err = writeHeader(f, compressor.Id(), codec.Id(), keyCodec.Id())
 	if err != nil {
+		f.Close()
 		return fmt.Errorf("write file header: %v", err)
 	}
+
+	if config != nil && config.Sync != SyncNone {
+		err = f.Sync()
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("sync file: %v", err)
+		}
+	}
+
 	err = f.Close()
 	if err != nil {
 		return fmt.Errorf("close file: %v", err)
 	}
 
-	return nil
-}
-
-func (db *Db) readAllBlocks() error {
-	f, err := os.Open(db.filePath)
+	err = os.Chmod(f.Name(), 0644)
 	if err != nil {
-		return fmt.Errorf("open file: %v", err)
+		return fmt.Errorf("chmod file: %v", err)
 	}
-	defer f.Close()
 
-	_, err = f.Seek(headerLength, io.SeekStart)
-	if err != nil {
-		return fmt.Errorf("file seek: %v", err)
+	err = os.Link(f.Name(), filePath)
+	if os.IsExist(err) {
+		return nil // created by concurrent opener
+	} else if err != nil {
+		return fmt.Errorf("link file: %v", err)
 	}
 
-	for {
-		blockStartPos, err := f.Seek(0, io.SeekCurrent)
+	if config != nil && config.Sync != SyncNone {
+		err = syncDir(dir)
 		if err != nil {
-			return err
+			return fmt.Errorf("sync directory: %v", err)
 		}
+	}
 
-		blockData, err := readBlock(f, db.config.Compressor)
-		if err == io.EOF {
+	return nil
+}
+
+// readBlocks reads blocks located after db.dataEnd and applies their records.
+// Incompletely written last block of read only storage is left for next call.
+func (db *Db) readBlocks() error {
+	start := db.dataEnd
+	r := io.NewSectionReader(db.f, start, math.MaxInt64-start)
+
+	for {
+		blockData, err := readBlock(r, db.config.Compressor)
+		if err == io.EOF || (err == io.ErrUnexpectedEOF && db.config.ReadOnly) {
 			break
 		} else if err != nil {
 			return err
 		}
 
+		blockStartPos := db.dataEnd
+		pos, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		db.dataEnd = start + pos
+
 		db.blockInfo[db.currentBlockNum] = blockStartPos
 		blockDataReader := bytes.NewReader(blockData)
 
+		// restored write buffer is written again as new block,
+		// so records of previous block must not be applied twice
+		if len(db.lastBlockBytes) > 0 && bytes.HasPrefix(blockData, db.lastBlockBytes) {
+			blockDataReader.Seek(int64(len(db.lastBlockBytes)), io.SeekStart)
+		}
+		db.lastBlockBytes = blockData
+
 		for {
 			recordOffset, err := blockDataReader.Seek(0, io.SeekCurrent)
 			if err != nil {
 				return err
 			}
 
-			action, keyBytes, _, err := readRecord(blockDataReader)
+			action, keyBytes, valueBytes, err := readRecord(blockDataReader)
 			if err == io.EOF {
 				break
 			} else if err != nil {
 				return err
 			}
 
-			switch action {
-			case actionAdd:
-				db.keys[string(keyBytes)] = coords{blockNum: db.currentBlockNum, recordOffset: recordOffset}
-			case actionDelete:
-				if _, exists := db.keys[string(keyBytes)]; !exists {
-					return fmt.Errorf("unexpected delete of key %v because it is does not exists", keyBytes)
-				}
-				delete(db.keys, string(keyBytes))
-			default:
-				return fmt.Errorf("unknown action: %d for key %v", action, keyBytes)
+			err = db.replayRecord(action, keyBytes, valueBytes, coords{blockNum: db.currentBlockNum, recordOffset: recordOffset})
+			if err != nil {
+				return err
 			}
 		}
 
@@ -202,18 +381,7 @@ func (db *Db) restoreWriteBuffer() error {
 		return fmt.Errorf("last block #%d is not present in db.blockInfo", db.currentBlockNum-1)
 	}
 
-	f, err := os.Open(db.filePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	_, err = f.Seek(offset, io.SeekStart)
-	if err != nil {
-		return err
-	}
-
-	blockBytes, err := readBlock(f, db.config.Compressor)
+	blockBytes, err := db.getBlockBytesFromFile(offset)
 	if err != nil {
 		return err
 	}
@@ -237,6 +405,10 @@ func (db *Db) Set(key interface{}, value interface{}) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	if db.closed {
+		return ErrClosed
+	}
+
 	if db.config.ReadOnly {
 		return errReadOnly
 	}
@@ -245,12 +417,12 @@ func (db *Db) Set(key interface{}, value interface{}) error {
 }
 
 func (db *Db) set(key interface{}, value interface{}) error {
-	keyBytes, err := Encode(key)
+	keyBytes, err := db.config.KeyCodec.Encode(key)
 	if err != nil {
 		return err
 	}
 
-	valueBytes, err := Encode(value)
+	valueBytes, err := db.config.Codec.Encode(value)
 	if err != nil {
 		return err
 	}
@@ -263,31 +435,129 @@ func (db *Db) Get(key interface{}, valuePtr interface{}) error {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
+	if db.closed {
+		return ErrClosed
+	}
+
 	return db.get(key, valuePtr)
 }
 
 func (db *Db) get(key interface{}, valuePtr interface{}) error {
-	keyBytes, err := Encode(key)
+	keyBytes, err := db.config.KeyCodec.Encode(key)
+	if err != nil {
+		return err
+	}
+
+	valueBytes, err := db.getBytes(keyBytes)
 	if err != nil {
 		return err
 	}
 
-	action, gotKeyBytes, valueBytes, err := db.getRecord(keyBytes)
+	err = db.config.Codec.Decode(valueBytes, valuePtr)
 	if err != nil {
 		return err
 	}
 
+	return nil
+}
+
+func (db *Db) getBytes(keyBytes []byte) ([]byte, error) {
+	action, _, valueBytes, err := db.getRecord(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+
 	if action != actionAdd {
-		return fmt.Errorf("expected %v action, got %v", actionAdd, action)
+		return nil, fmt.Errorf("expected %v action, got %v", actionAdd, action)
 	}
 
-	if !bytes.Equal(gotKeyBytes, keyBytes) {
-		return fmt.Errorf("expected read %v key, got %v", keyBytes, gotKeyBytes)
+	return valueBytes, nil
+}
+
+// GetMany reads values of specified keys under single read lock.
+// Keys are grouped by block so every block is read and decompressed only once.
+// into is called with index of key in keys and encoded value bytes
+// for every existing key, missing keys are skipped.
+func (db *Db) GetMany(keys []interface{}, into func(i int, valueBytes []byte) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
 	}
 
-	err = Decode(valueBytes, valuePtr)
-	if err != nil {
-		return err
+	return db.getMany(keys, into)
+}
+
+// GetManyInto decodes values of specified keys into corresponding valuePtrs.
+// Values of missing keys are left untouched.
+func (db *Db) GetManyInto(keys []interface{}, valuePtrs []interface{}) error {
+	if len(keys) != len(valuePtrs) {
+		return fmt.Errorf("got %d keys and %d value pointers", len(keys), len(valuePtrs))
+	}
+
+	return db.GetMany(keys, func(i int, valueBytes []byte) error {
+		return db.config.Codec.Decode(valueBytes, valuePtrs[i])
+	})
+}
+
+func (db *Db) getMany(keys []interface{}, into func(i int, valueBytes []byte) error) error {
+	type request struct {
+		i            int
+		keyBytes     []byte
+		recordOffset int64
+	}
+
+	requests := make(map[int64][]request) // [block number]requests
+	var blockNums []int64
+
+	for i, key := range keys {
+		keyBytes, err := db.config.KeyCodec.Encode(key)
+		if err != nil {
+			return err
+		}
+
+		c, exists, err := db.keys.get(keyBytes)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		if _, exists := requests[c.blockNum]; !exists {
+			blockNums = append(blockNums, c.blockNum)
+		}
+		requests[c.blockNum] = append(requests[c.blockNum], request{i, keyBytes, c.recordOffset})
+	}
+
+	sort.Slice(blockNums, func(i, j int) bool { return blockNums[i] < blockNums[j] })
+
+	for _, blockNum := range blockNums {
+		blockBytes, err := db.getBlockBytes(blockNum)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range requests[blockNum] {
+			action, gotKeyBytes, valueBytes, err := readRecordAt(blockBytes, r.recordOffset)
+			if err != nil {
+				return err
+			}
+
+			if action != actionAdd {
+				return fmt.Errorf("expected %v action, got %v", actionAdd, action)
+			}
+
+			if !bytes.Equal(gotKeyBytes, r.keyBytes) {
+				continue // hash collision
+			}
+
+			err = into(r.i, valueBytes)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -298,41 +568,100 @@ func (db *Db) Flush() error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	if db.closed {
+		return ErrClosed
+	}
+
 	return db.flush()
 }
 
 func (db *Db) flush() error {
-	if db.buf.Len() == 0 {
+	// read only storage has no write handle, its blocks are written by other process
+	if db.buf.Len() == 0 || db.config.ReadOnly {
 		return nil
 	}
 
-	f, err := os.OpenFile(db.filePath, os.O_WRONLY|os.O_APPEND, 0644)
+	blockOffset, err := db.w.Seek(0, io.SeekEnd)
 	if err != nil {
 		return err
 	}
-
-	blockOffset, err := f.Seek(0, io.SeekEnd)
+	err = writeBlock(db.w, db.config.Compressor, db.buf.Bytes())
 	if err != nil {
 		return err
 	}
-	err = writeBlock(f, db.config.Compressor, db.buf.Bytes())
-	if err != nil {
-		return err
+
+	if db.config.Sync != SyncNone {
+		err = db.w.Sync()
+		if err != nil {
+			return err
+		}
 	}
 
 	db.buf.Reset()
 	db.blockInfo[db.currentBlockNum] = blockOffset
 	db.currentBlockNum++
 
-	return f.Close()
+	db.dataEnd, err = db.w.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	if db.mmap != nil {
+		return db.mmap.grow(db.f.Fd(), db.dataEnd)
+	}
+
+	return nil
 }
 
 // Close saves buffered data and closes storage.
+// Any further calls return ErrClosed.
 func (db *Db) Close() error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	return db.flush()
+	if db.closed {
+		return ErrClosed
+	}
+
+	err := db.flush()
+	if err != nil {
+		return err
+	}
+
+	db.closed = true
+	db.closeWatchers()
+
+	if db.stopRefresh != nil {
+		close(db.stopRefresh)
+	}
+
+	return db.closeFiles()
+}
+
+func (db *Db) closeFiles() error {
+	var err error
+
+	if db.keys != nil {
+		err = db.keys.close()
+	}
+
+	if db.mmap != nil {
+		if closeErr := db.mmap.close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if db.w != nil {
+		if closeErr := db.w.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if closeErr := db.f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
 }
 
 // Count returns number of stored key/value pairs.
@@ -340,7 +669,7 @@ func (db *Db) Count() int {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	return len(db.keys)
+	return db.keys.len()
 }
 
 // Delete deletes value of specified key.
@@ -348,6 +677,10 @@ func (db *Db) Delete(key interface{}) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	if db.closed {
+		return ErrClosed
+	}
+
 	if db.config.ReadOnly {
 		return errReadOnly
 	}
@@ -356,23 +689,32 @@ func (db *Db) Delete(key interface{}) error {
 }
 
 func (db *Db) delete(key interface{}) error {
-	keyBytes, err := Encode(key)
+	keyBytes, err := db.config.KeyCodec.Encode(key)
 	if err != nil {
 		return err
 	}
 
-	if _, exists := db.keys[string(keyBytes)]; !exists {
-		return nil
+	return db.deleteBytes(keyBytes)
+}
+
+func (db *Db) deleteBytes(keyBytes []byte) error {
+	// index compares full keys, so key with same hash is not found here
+	_, exists, err := db.keys.get(keyBytes)
+	if err != nil || !exists {
+		return err
 	}
 
-	err = writeRecord(&db.buf, actionDelete, keyBytes, nil)
+	c, err := db.appendRecord(actionDelete, keyBytes, nil)
 	if err != nil {
 		return err
 	}
 
-	delete(db.keys, string(keyBytes))
+	err = db.applyRecord(actionDelete, keyBytes, nil, c)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return db.syncWrite()
 }
 
 // Shrink compacts storage by removing replaced records and saves new file to
@@ -381,6 +723,10 @@ func (db *Db) Shrink(filePath string) error {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
+	if db.closed {
+		return ErrClosed
+	}
+
 	fileExists := func(filename string) bool {
 		info, err := os.Stat(filename)
 		if os.IsNotExist(err) {
@@ -393,60 +739,135 @@ func (db *Db) Shrink(filePath string) error {
 		return fmt.Errorf("file %s must not exists", filePath)
 	}
 
-	shrinkedDb, err := OpenWithConfig(filePath, &Config{BlockDataSize: db.config.BlockDataSize})
+	shrinkedDb, err := OpenWithConfig(filePath, &Config{
+		BlockDataSize:  db.config.BlockDataSize,
+		Codec:          db.config.Codec,
+		KeyCodec:       db.config.KeyCodec,
+		Index:          db.config.Index,
+		IndexCacheSize: db.config.IndexCacheSize})
 	if err != nil {
 		shrinkedDb.Close()
 		os.Remove(filePath)
 		return err
 	}
 
-	var keysBytes [][]byte
-	for keyBytes := range db.keys {
-		keysBytes = append(keysBytes, []byte(keyBytes))
+	var records []coords
+	err = db.keys.each(func(_ []byte, c coords) bool {
+		records = append(records, c)
+		return true
+	})
+	if err != nil {
+		return err
 	}
 
-	for _, keysBytes := range keysBytes {
-		action, gotKeyBytes, valueBytes, err := db.getRecord([]byte(keysBytes))
+	// read records in written order to decompress every block only once
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].blockNum != records[j].blockNum {
+			return records[i].blockNum < records[j].blockNum
+		}
+		return records[i].recordOffset < records[j].recordOffset
+	})
+
+	var blockBytes []byte
+	for i, c := range records {
+		if i == 0 || records[i-1].blockNum != c.blockNum {
+			blockBytes, err = db.getBlockBytes(c.blockNum)
+			if err != nil {
+				return err
+			}
+		}
+
+		action, keyBytes, valueBytes, err := readRecordAt(blockBytes, c.recordOffset)
 		if err != nil {
 			return err
 		}
-		if !bytes.Equal(gotKeyBytes, []byte(keysBytes)) {
-			return fmt.Errorf("expected %v key bytes, got %v", keysBytes, gotKeyBytes)
-		}
 		if action != actionAdd {
 			return fmt.Errorf("expected %v action, got %v", actionAdd, action)
 		}
 
-		shrinkedDb.writeRecord(action, keysBytes, valueBytes)
+		shrinkedDb.writeRecord(action, keyBytes, valueBytes)
 	}
 
 	return shrinkedDb.Close()
 }
 
 func (db *Db) writeRecord(action action, keyBytes []byte, valueBytes []byte) error {
+	c, err := db.appendRecord(action, keyBytes, valueBytes)
+	if err != nil {
+		return err
+	}
+
+	err = db.applyRecord(action, keyBytes, valueBytes, c)
+	if err != nil {
+		return err
+	}
+
+	return db.syncWrite()
+}
+
+// appendRecord writes record to write buffer without applying it to key index.
+// Buffer is flushed if it reaches block size.
+func (db *Db) appendRecord(action action, keyBytes []byte, valueBytes []byte) (coords, error) {
 	c := coords{
 		blockNum:     db.currentBlockNum,
 		recordOffset: int64(db.buf.Len())}
 
-	err := writeRecord2(&db.buf, actionAdd, keyBytes, valueBytes)
+	err := writeRecord2(&db.buf, action, keyBytes, valueBytes)
 	if err != nil {
-		return err
+		return coords{}, err
 	}
-	db.keys[string(keyBytes)] = c
 
 	if int64(db.buf.Len()) >= db.config.BlockDataSize {
 		err = db.flush()
 		if err != nil {
-			return err
+			return coords{}, err
 		}
 	}
 
-	return nil
+	return c, nil
+}
+
+// applyRecord applies add or delete record located at specified coords to key index.
+func (db *Db) applyRecord(action action, keyBytes []byte, valueBytes []byte, c coords) error {
+	err := db.preserveForSnapshots(keyBytes)
+	if err != nil {
+		return err
+	}
 
+	switch action {
+	case actionAdd:
+		err = db.keys.set(keyBytes, c)
+		if err != nil {
+			return err
+		}
+		db.notify(ActionSet, keyBytes, valueBytes)
+
+		return nil
+	case actionDelete:
+		_, exists, err := db.keys.get(keyBytes)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("unexpected delete of key %v because it is does not exists", keyBytes)
+		}
+		err = db.keys.delete(keyBytes)
+		if err != nil {
+			return err
+		}
+		db.notify(ActionDelete, keyBytes, nil)
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown action: %d for key %v", action, keyBytes)
 }
 
 func (db *Db) getRecord(keyBytes []byte) (action action, rKeyBytes []byte, valueBytes []byte, err error) {
-	coords, exists := db.keys[string(keyBytes)]
+	coords, exists, err := db.keys.get(keyBytes)
+	if err != nil {
+		return actionNone, nil, nil, err
+	}
 	if !exists {
 		return actionNone, nil, nil, ErrNotFound
 	}
@@ -456,13 +877,17 @@ func (db *Db) getRecord(keyBytes []byte) (action action, rKeyBytes []byte, value
 		return actionNone, nil, nil, err
 	}
 
-	blockBytesReader := bytes.NewReader(blockBytes)
-	_, err = blockBytesReader.Seek(coords.recordOffset, io.SeekStart)
+	action, rKeyBytes, valueBytes, err = readRecordAt(blockBytes, coords.recordOffset)
 	if err != nil {
 		return actionNone, nil, nil, err
 	}
 
-	return readRecord(blockBytesReader)
+	// index may store only key hashes
+	if !bytes.Equal(rKeyBytes, keyBytes) {
+		return actionNone, nil, nil, ErrNotFound
+	}
+
+	return action, rKeyBytes, valueBytes, nil
 }
 
 func (db *Db) getBlockBytes(blockNum int64) ([]byte, error) {
@@ -485,25 +910,24 @@ func (db *Db) getBlockBytes(blockNum int64) ([]byte, error) {
 }
 
 func (db *Db) getBlockBytesFromFile(offset int64) ([]byte, error) {
-	f, err := os.Open(db.filePath)
-	if err != nil {
-		return nil, fmt.Errorf("open file: %v", err)
+	if db.mmap != nil {
+		// mapping may be larger than file, pages after file end can't be read
+		return readBlockFromBytes(db.mmap.data[:min(int64(len(db.mmap.data)), db.dataEnd)], offset, db.config.Compressor)
 	}
-	defer f.Close()
 
-	_, err = f.Seek(offset, io.SeekStart)
-	if err != nil {
-		return nil, fmt.Errorf("file seek: %v", err)
-	}
-
-	return readBlock(f, db.config.Compressor)
+	return readBlock(io.NewSectionReader(db.f, offset, math.MaxInt64-offset), db.config.Compressor)
 }
 
 // Iterate provedes fastest possible method of all record iteration.
+// f must not modify storage, use IterateMutable for it.
 func (db *Db) Iterate(f func(gobKeyBytes, gobValueBytes []byte) (continueIteration bool)) error {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
+	if db.closed {
+		return ErrClosed
+	}
+
 	for i := int64(0); i <= db.currentBlockNum; i++ {
 		blockBytes, err := db.getBlockBytes(i)
 		if err != nil {
@@ -524,17 +948,69 @@ func (db *Db) Iterate(f func(gobKeyBytes, gobValueBytes []byte) (continueIterati
 				return err
 			}
 
-			if _, exists := db.keys[string(keyBytes)]; !exists {
+			live, err := db.isLive(action, keyBytes, coords{blockNum: i, recordOffset: recordOffset})
+			if err != nil {
+				return err
+			}
+			if !live {
 				continue
 			}
 
-			if action != actionAdd ||
-				db.keys[string(keyBytes)].blockNum != i ||
-				db.keys[string(keyBytes)].recordOffset != recordOffset {
+			if !f(keyBytes, valueBytes) {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+// IterateMutable iterates over all records in written order like Iterate,
+// but f may modify storage. Records are taken from snapshot created at call
+// moment: records written during iteration are not visited, records changed
+// or deleted during iteration are visited with values they had at call moment.
+func (db *Db) IterateMutable(f func(keyBytes, valueBytes []byte) (continueIteration bool)) error {
+	snap, err := db.Snapshot()
+	if err != nil {
+		return err
+	}
+	defer snap.Close()
+
+	return snap.Iterate(f)
+}
+
+// IterateReverse iterates over all records from newest to oldest.
+func (db *Db) IterateReverse(f func(gobKeyBytes, gobValueBytes []byte) (continueIteration bool)) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	for i := db.currentBlockNum; i >= 0; i-- {
+		blockBytes, err := db.getBlockBytes(i)
+		if err != nil {
+			return err
+		}
+
+		records, err := readBlockRecords(blockBytes)
+		if err != nil {
+			return err
+		}
+
+		for j := len(records) - 1; j >= 0; j-- {
+			r := records[j]
+
+			live, err := db.isLive(r.action, r.keyBytes, coords{blockNum: i, recordOffset: r.offset})
+			if err != nil {
+				return err
+			}
+			if !live {
 				continue
 			}
 
-			if !f(keyBytes, valueBytes) {
+			if !f(r.keyBytes, r.valueBytes) {
 				return nil
 			}
 		}
@@ -542,3 +1018,102 @@ func (db *Db) Iterate(f func(gobKeyBytes, gobValueBytes []byte) (continueIterati
 
 	return nil
 }
+
+// ParallelIterate iterates over all records in unspecified order decoding
+// blocks concurrently on specified number of goroutines
+// (runtime.NumCPU() if workers <= 0). f must be safe for concurrent use.
+func (db *Db) ParallelIterate(workers int, f func(gobKeyBytes, gobValueBytes []byte) (continueIteration bool)) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	blockNums := make(chan int64)
+	errs := make(chan error, workers)
+	var stopped int32
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for blockNum := range blockNums {
+				// block numbers are drained so producer never blocks
+				if atomic.LoadInt32(&stopped) != 0 {
+					continue
+				}
+
+				err := db.iterateBlock(blockNum, &stopped, f)
+				if err != nil {
+					atomic.StoreInt32(&stopped, 1)
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	for i := int64(0); i <= db.currentBlockNum && atomic.LoadInt32(&stopped) == 0; i++ {
+		blockNums <- i
+	}
+	close(blockNums)
+
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+func (db *Db) iterateBlock(blockNum int64, stopped *int32, f func(keyBytes, valueBytes []byte) bool) error {
+	blockBytes, err := db.getBlockBytes(blockNum)
+	if err != nil {
+		return err
+	}
+
+	records, err := readBlockRecords(blockBytes)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		if atomic.LoadInt32(stopped) != 0 {
+			return nil
+		}
+
+		live, err := db.isLive(r.action, r.keyBytes, coords{blockNum: blockNum, recordOffset: r.offset})
+		if err != nil {
+			return err
+		}
+		if !live {
+			continue
+		}
+
+		if !f(r.keyBytes, r.valueBytes) {
+			atomic.StoreInt32(stopped, 1)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// isLive reports whether record located at specified coords
+// is the actual record of its key.
+func (db *Db) isLive(action action, keyBytes []byte, recordCoords coords) (bool, error) {
+	if action != actionAdd {
+		return false, nil
+	}
+
+	c, exists, err := db.keys.get(keyBytes)
+	if err != nil {
+		return false, err
+	}
+
+	return exists && c == recordCoords, nil
+}
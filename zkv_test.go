@@ -1,8 +1,14 @@
 package zkv
 
 import (
+	"errors"
+	"io"
 	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -17,7 +23,7 @@ func TestInitFile(t *testing.T) {
 
 	stat, err := os.Stat(filePath)
 	assert.NoError(t, err)
-	assert.EqualValues(t, 5, stat.Size())
+	assert.EqualValues(t, 7, stat.Size())
 }
 
 func TestFlush(t *testing.T) {
@@ -118,7 +124,7 @@ func TestReadFile(t *testing.T) {
 	blockInMemBytes := append([]byte{}, db.buf.Bytes()...)
 	bytesInMem := db.buf.Len()
 	currentBlockNum := db.currentBlockNum
-	storedKeys := len(db.keys)
+	storedKeys := db.keys.len()
 
 	err = db.Close()
 	assert.NoError(t, err)
@@ -130,7 +136,7 @@ func TestReadFile(t *testing.T) {
 	assert.EqualValues(t, db.currentBlockNum, currentBlockNum)
 	assert.Len(t, db.blockInfo, blockOnDisk)
 	assert.EqualValues(t, bytesInMem, db.buf.Len())
-	assert.Len(t, db.keys, storedKeys)
+	assert.Equal(t, storedKeys, db.keys.len())
 	assert.Equal(t, blockInMemBytes, append([]byte{}, db.buf.Bytes()...))
 
 	for i := int64(0); i < expectedRecordCount; i++ {
@@ -426,3 +432,331 @@ func TestOneMillionArray(t *testing.T) {
 	err = db.Close()
 	assert.NoError(t, err)
 }
+
+func TestClosed(t *testing.T) {
+	const filePath = "closed.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	err = db.Set(1, 1)
+	assert.NoError(t, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	err = db.Close()
+	assert.Equal(t, ErrClosed, err)
+
+	err = db.Set(2, 2)
+	assert.Equal(t, ErrClosed, err)
+
+	var got int
+	err = db.Get(1, &got)
+	assert.Equal(t, ErrClosed, err)
+
+	err = db.Delete(1)
+	assert.Equal(t, ErrClosed, err)
+
+	err = db.Flush()
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestConcurrentReads(t *testing.T) {
+	const filePath = "concurrentReads.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, &Config{BlockDataSize: 64})
+	assert.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 1000; i++ {
+				var got int
+				err := db.Get(i, &got)
+				assert.NoError(t, err)
+				assert.Equal(t, i, got)
+			}
+		}()
+	}
+	wg.Wait()
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestMmap(t *testing.T) {
+	const filePath = "mmap.tmp"
+	defer os.Remove(filePath)
+
+	config := &Config{BlockDataSize: 1024, Compressor: NoneCompressor, Mmap: true}
+
+	db, err := OpenWithConfig(filePath, config)
+	assert.NoError(t, err)
+
+	// enough data to remap file several times
+	value := make([]byte, 64*1024)
+	for i := 0; i < 100; i++ {
+		value[0] = byte(i)
+		err = db.Set(i, value)
+		assert.NoError(t, err)
+	}
+	assert.True(t, len(db.mmap.retired) > 0)
+
+	for i := 0; i < 100; i++ {
+		var got []byte
+		err = db.Get(i, &got)
+		assert.NoError(t, err)
+		assert.Equal(t, byte(i), got[0])
+	}
+
+	err = db.Close()
+	assert.NoError(t, err)
+
+	db, err = OpenWithConfig(filePath, config)
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		var got []byte
+		err = db.Get(i, &got)
+		assert.NoError(t, err)
+		assert.Equal(t, byte(i), got[0])
+	}
+
+	// offset inside mapping, but after file end
+	_, err = db.getBlockBytesFromFile(db.dataEnd + 64*1024)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestGetMany(t *testing.T) {
+	const filePath = "getMany.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, &Config{BlockDataSize: 64})
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		err = db.Set(i, i*10)
+		assert.NoError(t, err)
+	}
+
+	keys := []interface{}{99, 0, 1000, 50, 1}
+	got := make([]int, len(keys))
+	valuePtrs := make([]interface{}, len(keys))
+	for i := range got {
+		got[i] = -1
+		valuePtrs[i] = &got[i]
+	}
+
+	err = db.GetManyInto(keys, valuePtrs)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{990, 0, -1, 500, 10}, got)
+
+	var calls int
+	err = db.GetMany(keys, func(i int, valueBytes []byte) error {
+		calls++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, calls)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestIterateReverse(t *testing.T) {
+	const filePath = "iterateReverse.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, &Config{BlockDataSize: 64})
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+	err = db.Delete(98)
+	assert.NoError(t, err)
+	err = db.Set(0, 0) // move to the end
+	assert.NoError(t, err)
+
+	var got []int
+	err = db.IterateReverse(func(k, v []byte) bool {
+		var key int
+		err := Decode(k, &key)
+		assert.NoError(t, err)
+
+		got = append(got, key)
+		return len(got) < 4
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 99, 97, 96}, got)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestParallelIterate(t *testing.T) {
+	const filePath = "parallelIterate.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, &Config{BlockDataSize: 64})
+	assert.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		err = db.Set(i%500, i)
+		assert.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	var sum int
+	err = db.ParallelIterate(4, func(k, v []byte) bool {
+		var value int
+		err := Decode(v, &value)
+		assert.NoError(t, err)
+
+		mu.Lock()
+		sum += value
+		mu.Unlock()
+
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, (500+999)*500/2, sum)
+
+	var count int32
+	err = db.ParallelIterate(0, func(k, v []byte) bool {
+		return atomic.AddInt32(&count, 1) < 10
+	})
+	assert.NoError(t, err)
+	assert.True(t, count < 500)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+// slowFailingCompressor fails to decompress blocks after delay
+type slowFailingCompressor struct {
+	Compressor
+}
+
+func (c slowFailingCompressor) Decompress([]byte) ([]byte, error) {
+	time.Sleep(10 * time.Millisecond)
+	return nil, errors.New("corrupted block")
+}
+
+func TestParallelIterateError(t *testing.T) {
+	const filePath = "parallelIterateError.tmp"
+	defer os.Remove(filePath)
+
+	db, err := OpenWithConfig(filePath, &Config{BlockDataSize: 64})
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+	err = db.Flush()
+	assert.NoError(t, err)
+	assert.True(t, db.currentBlockNum > 3)
+
+	compressor := db.config.Compressor
+	db.config.Compressor = slowFailingCompressor{compressor}
+
+	done := make(chan error)
+	go func() {
+		done <- db.ParallelIterate(1, func(k, v []byte) bool {
+			return true
+		})
+	}()
+
+	select {
+	case err = <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ParallelIterate hangs after block read error")
+	}
+
+	db.config.Compressor = compressor
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestIterateMutable(t *testing.T) {
+	const filePath = "iterateMutable.tmp"
+	defer os.Remove(filePath)
+
+	db, err := Open(filePath)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err = db.Set(i, i)
+		assert.NoError(t, err)
+	}
+
+	var visited []int
+	err = db.IterateMutable(func(keyBytes, valueBytes []byte) bool {
+		var key int
+		err := db.config.KeyCodec.Decode(keyBytes, &key)
+		assert.NoError(t, err)
+		visited = append(visited, key)
+
+		if key%2 == 0 {
+			err = db.Delete(key)
+		} else {
+			err = db.Set(key+10, key) // new records are not visited
+		}
+		assert.NoError(t, err)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, visited)
+	assert.Equal(t, 10, db.Count())
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestConcurrentCreate(t *testing.T) {
+	const filePath = "concurrentCreate.tmp"
+	defer os.Remove(filePath)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			db, err := OpenWithConfig(filePath, &Config{Lock: LockNone})
+			if err != nil {
+				errs <- err
+				return
+			}
+			errs <- db.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	tmpFiles, err := filepath.Glob(filePath + ".*.tmp")
+	assert.NoError(t, err)
+	assert.Empty(t, tmpFiles)
+}